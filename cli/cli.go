@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -19,6 +20,8 @@ import (
 	"github.com/fholmqvist/remlisp/runtime"
 	"github.com/fholmqvist/remlisp/stdlib"
 	"github.com/fholmqvist/remlisp/transpiler"
+	"github.com/fholmqvist/remlisp/transpiler/js"
+	"github.com/fholmqvist/remlisp/transpiler/mangle"
 )
 
 func Run() {
@@ -32,18 +35,23 @@ func Run() {
 	}
 }
 
-func setup() (*arg.Parser, Settings, *compiler.Compiler, *expander.Expander, *runtime.Runtime, string) {
+func setup() (*arg.Parser, Settings, *compiler.Compiler, *expander.Expander, *runtime.Runtime, []js.Stmt) {
 	var settings Settings
 	parg := arg.MustParse(&settings)
 	lexer := lexer.New()
-	parser, transpiler := parser.New(lexer), transpiler.New()
+	parser := parser.New(lexer)
+	transpiler := transpiler.NewWithOptions(transpiler.Options{
+		JSTruthiness: settings.JSTruthiness,
+		NilUndefined: settings.NilUndefined,
+		Persistent:   settings.Persistent,
+	})
 	rt, erre := runtime.New()
 	if erre != nil {
 		exite("creating runtime", []byte{}, erre)
 	}
 	exp := expander.New(lexer, parser, transpiler, rt)
 	cmp := compiler.New(lexer, parser, transpiler)
-	stdfns, erre := cmp.Compile(stdlib.StdFns, exp)
+	stdfns, erre := cmp.CompileProgram(stdlib.StdFns, exp)
 	if erre != nil {
 		exite("compiling stdlib functions", stdlib.StdFns, erre)
 	}
@@ -51,7 +59,7 @@ func setup() (*arg.Parser, Settings, *compiler.Compiler, *expander.Expander, *ru
 	if erre != nil {
 		exite("compiling stdlib macros", stdlib.StdMacros, erre)
 	}
-	rt.Send(stdfns)
+	rt.Send(js.Print(stdfns, js.Options{}))
 	rt.Send(stdmacros)
 	return parg, settings, cmp, exp, rt, stdfns
 }
@@ -61,15 +69,19 @@ func runRepl(cmp *compiler.Compiler, exp *expander.Expander, rt *runtime.Runtime
 	repl.Run(cmp, exp, rt)
 }
 
-func runFile(settings Settings, cmp *compiler.Compiler, exp *expander.Expander, stdfns string) {
+func runFile(settings Settings, cmp *compiler.Compiler, exp *expander.Expander, stdfns []js.Stmt) {
 	if settings.Debug {
 		print.Logo()
 	}
-	input, code, erre := cmp.CompileFile(settings.Path, settings.Debug, exp)
+	out, err := settings.output()
+	if err != nil {
+		exit("printing output", err)
+	}
+	input, program, erre := cmp.CompileFile(settings.Path, settings.Debug, exp)
 	if erre != nil {
 		exite("reading input", input, erre)
 	}
-	result := fmt.Sprintf("%s\n\n// ========\n// stdlib\n// ========\n\n%s", code, stdfns)
+	result := build(program, stdfns, out)
 	outfile := "out.js"
 	if settings.Out != "" {
 		outfile = settings.Out
@@ -80,11 +92,11 @@ func runFile(settings Settings, cmp *compiler.Compiler, exp *expander.Expander,
 	if err := os.WriteFile(outfile, []byte(result), os.ModePerm); err != nil {
 		exit("creating output file", err)
 	}
-	if _, err := exec.Command("deno", "fmt", outfile).Output(); err != nil {
-		exit("deno fmt", err)
-	}
 	if settings.Run {
 		bb, err := exec.Command("deno", "run", "--allow-read", outfile).Output()
+		if err, ok := err.(*exec.ExitError); ok && len(err.Stderr) > 0 {
+			exit("deno", errors.New(mangle.Text(string(err.Stderr))))
+		}
 		if err != nil {
 			exit("deno", err)
 		}
@@ -92,6 +104,18 @@ func runFile(settings Settings, cmp *compiler.Compiler, exp *expander.Expander,
 	}
 }
 
+// The output file, which is the program
+// between the runtime and the stdlib.
+func build(program, stdfns []js.Stmt, out js.Options) string {
+	if out.Minify {
+		js.RenameLocals(stdfns)
+		js.RenameLocals(program)
+		return strings.Join([]string{string(stdlib.Minify(stdlib.StdJS)), js.Print(program, out), js.Print(stdfns, out)}, "\n")
+	}
+	return fmt.Sprintf("%s\n%s\n\n// ========\n// stdlib\n// ========\n\n%s\n",
+		stdlib.StdJS, js.Print(program, out), js.Print(stdfns, out))
+}
+
 func showUsage(parg *arg.Parser) {
 	print.Logo()
 	parg.WriteUsage(os.Stdout)
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fholmqvist/remlisp/stdlib"
+	"github.com/fholmqvist/remlisp/transpiler/js"
+)
+
+func TestBuildMinified(t *testing.T) {
+	full := build(nil, nil, js.Options{Indent: "  "})
+	minified := build(nil, nil, js.Options{Minify: true})
+	if len(minified) > len(full)*2/3 {
+		t.Fatalf("expected the minified output to be at most 2/3 of %d bytes, got %d", len(full), len(minified))
+	}
+	for _, s := range []string{"MIT License", "// ", "/*", "  "} {
+		if strings.Contains(minified, s) {
+			t.Fatalf("expected the minified output not to contain %q", s)
+		}
+	}
+	if !strings.Contains(full, "MIT License") || !strings.HasPrefix(full, string(stdlib.StdJS)) {
+		t.Fatal("expected the output to start with the runtime as it is")
+	}
+}
+
+func TestRunMinified(t *testing.T) {
+	if _, err := exec.LookPath("deno"); err != nil {
+		t.Skip("deno is not installed")
+	}
+	program := "console.log(String(_atom('b')), String(_symbol('a')), _equals(_vector(1, 2), [1, 2]))"
+	for _, out := range []js.Options{{Indent: "  "}, {Minify: true}} {
+		path := filepath.Join(t.TempDir(), "out.js")
+		if err := os.WriteFile(path, []byte(build(nil, nil, out)+program), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		bb, err := exec.Command("deno", "run", path).CombinedOutput()
+		if err != nil {
+			t.Fatalf("%s: %s", err, bb)
+		}
+		if got := strings.TrimSpace(string(bb)); got != ":b a true" {
+			t.Fatalf("expected %q, got %q", ":b a true", got)
+		}
+	}
+}
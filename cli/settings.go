@@ -1,9 +1,43 @@
 package cli
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fholmqvist/remlisp/transpiler/js"
+)
+
 type Settings struct {
 	Path  string `arg:"positional" help:"path to the input file"`
 	Out   string `arg:"-o, --out" help:"path of the output file"`
 	REPL  bool   `help:"start REPL"`
 	Run   bool   `help:"run the output (deno)"`
 	Debug bool   `help:"print debug info"`
+
+	JSTruthiness bool `arg:"--js-truthiness" help:"use JS truthiness in conditionals"`
+	NilUndefined bool `arg:"--nil-undefined" help:"compile nil to undefined instead of null"`
+	Persistent   bool `arg:"--persistent" help:"compile vectors and maps to persistent collections"`
+
+	Indent int    `default:"2" help:"spaces to indent the output with"`
+	Tabs   bool   `help:"indent the output with tabs"`
+	Width  int    `default:"80" help:"line width of the output"`
+	Quote  string `default:"double" help:"quotes of strings in the output, double or single"`
+	Minify bool   `help:"minify the output"`
+}
+
+// How the output file is printed.
+func (s Settings) output() (js.Options, error) {
+	opts := js.Options{Indent: strings.Repeat(" ", s.Indent), Width: s.Width, Minify: s.Minify}
+	if s.Tabs {
+		opts.Indent = "\t"
+	}
+	switch s.Quote {
+	case "double":
+		opts.Quote = '"'
+	case "single":
+		opts.Quote = '\''
+	default:
+		return opts, fmt.Errorf("unknown quote style: %q", s.Quote)
+	}
+	return opts, nil
 }
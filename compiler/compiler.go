@@ -6,11 +6,13 @@ import (
 
 	e "github.com/fholmqvist/remlisp/err"
 	"github.com/fholmqvist/remlisp/expander"
+	ex "github.com/fholmqvist/remlisp/expr"
 	h "github.com/fholmqvist/remlisp/highlight"
 	"github.com/fholmqvist/remlisp/lexer"
 	"github.com/fholmqvist/remlisp/parser"
 	"github.com/fholmqvist/remlisp/print"
 	"github.com/fholmqvist/remlisp/transpiler"
+	"github.com/fholmqvist/remlisp/transpiler/js"
 )
 
 type Compiler struct {
@@ -29,30 +31,62 @@ func New(l *lexer.Lexer, p *parser.Parser, t *transpiler.Transpiler) *Compiler {
 	}
 }
 
-func (c *Compiler) CompileFile(filename string, print bool, expander *expander.Expander) ([]byte, string, *e.Error) {
+func (c *Compiler) CompileFile(filename string, print bool, expander *expander.Expander) ([]byte, []js.Stmt, *e.Error) {
 	c.print = print
 	bb, err := os.ReadFile(filename)
 	if err != nil {
-		return bb, "", &e.Error{Msg: fmt.Sprintf("error reading file: %s", err)}
+		return bb, nil, &e.Error{Msg: fmt.Sprintf("error reading file: %s", err)}
 	}
-	code, erre := c.Compile(bb, expander)
+	program, erre := c.CompileProgram(bb, expander)
 	if erre != nil {
-		return bb, "", erre
+		return bb, nil, erre
 	}
-	return bb, code, nil
+	return bb, program, nil
 }
 
 func (c *Compiler) Compile(bb []byte, expander *expander.Expander) (string, *e.Error) {
+	exprs, err := c.expand(bb, expander)
+	if err != nil {
+		return "", err
+	}
+	code, err := c.trn.Transpile(exprs)
+	if err != nil {
+		return "", wrap("compile", err)
+	}
+	if c.print {
+		print.Code(code)
+	}
+	return code, nil
+}
+
+// Compiles to the statements of a program,
+// for printing with options.
+func (c *Compiler) CompileProgram(bb []byte, expander *expander.Expander) ([]js.Stmt, *e.Error) {
+	exprs, err := c.expand(bb, expander)
+	if err != nil {
+		return nil, err
+	}
+	program, err := c.trn.TranspileProgram(exprs)
+	if err != nil {
+		return nil, wrap("compile", err)
+	}
+	if c.print {
+		print.Code(js.Print(program, js.Options{Indent: "  "}))
+	}
+	return program, nil
+}
+
+func (c *Compiler) expand(bb []byte, expander *expander.Expander) ([]ex.Expr, *e.Error) {
 	tokens, err := c.lex.Lex(bb)
 	if err != nil {
-		return "", wrap("lexing", err)
+		return nil, wrap("lexing", err)
 	}
 	if c.print {
 		print.Tokens(tokens)
 	}
 	exprs, err := c.prs.Parse(tokens)
 	if err != nil {
-		return "", wrap("parse", err)
+		return nil, wrap("parse", err)
 	}
 	if c.print {
 		print.Exprs(exprs)
@@ -62,19 +96,12 @@ func (c *Compiler) Compile(bb []byte, expander *expander.Expander) (string, *e.E
 	}
 	exprs, err = expander.Expand(exprs, c.print)
 	if err != nil {
-		return "", wrap("expansion", err)
+		return nil, wrap("expansion", err)
 	}
 	if c.print {
 		print.Line()
 	}
-	code, err := c.trn.Transpile(exprs)
-	if err != nil {
-		return "", wrap("compile", err)
-	}
-	if c.print {
-		print.Code(code)
-	}
-	return code, nil
+	return exprs, nil
 }
 
 func wrap(msg string, err *e.Error) *e.Error {
@@ -46,6 +46,7 @@ type Expander struct {
 	macros []*ex.Macro
 
 	printouts int
+	gensyms   int
 
 	lex *lexer.Lexer
 	prs *parser.Parser
@@ -90,7 +91,8 @@ func (e *Expander) expand(expr ex.Expr) (ex.Expr, *er.Error) {
 	case *ex.Vec:
 		return e.expandVec(expr)
 	case *ex.Quote:
-		return expr.E, nil
+		// Quoted code is data, leave it be.
+		return expr, nil
 	case *ex.Unquote:
 		if !e.inQuasiquote() {
 			return nil, &er.Error{
@@ -114,30 +116,66 @@ func (e *Expander) expand(expr ex.Expr) (ex.Expr, *er.Error) {
 	case *ex.Quasiquote:
 		return e.expandQuasiquote(expr)
 	case *ex.Fn:
-		paramse, err := e.expand(expr.Params)
+		params, body, err := e.expandFn(expr.Params, expr.Body, expr.Arities)
 		if err != nil {
 			return nil, err
 		}
-		params, ok := paramse.(*ex.Vec)
-		if !ok {
-			return nil, &er.Error{
-				Msg:   "expected a vector of parameters",
-				Start: expr.Params.P.Start,
-				End:   expr.Params.P.End,
-			}
-		}
-		expr.Params = params
-		body, err := e.expand(expr.Body)
+		expr.Params, expr.Body = params, body
+		return expr, nil
+	case *ex.AnonymousFn:
+		params, body, err := e.expandFn(expr.Params, expr.Body, expr.Arities)
 		if err != nil {
 			return nil, err
 		}
-		expr.Body = body
+		expr.Params, expr.Body = params, body
 		return expr, nil
 	}
 	return expr, nil
 }
 
+// Expands the parameters and body of a function,
+// or of every arity for multi-arity functions.
+func (e *Expander) expandFn(params *ex.Vec, body ex.Expr, arities []ex.Arity) (*ex.Vec, ex.Expr, *er.Error) {
+	if len(arities) > 0 {
+		for i, a := range arities {
+			params, body, err := e.expandFn(a.Params, a.Body, nil)
+			if err != nil {
+				return nil, nil, err
+			}
+			arities[i].Params, arities[i].Body = params, body
+		}
+		return params, body, nil
+	}
+	paramse, err := e.expand(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	nparams, ok := paramse.(*ex.Vec)
+	if !ok {
+		return nil, nil, &er.Error{
+			Msg:   "expected a vector of parameters",
+			Start: params.P.Start,
+			End:   params.P.End,
+		}
+	}
+	nbody, err := e.expand(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nparams, nbody, nil
+}
+
 func (e *Expander) expandCall(list *ex.List) (ex.Expr, *er.Error) {
+	if thread, ok := e.findThreading(list); ok {
+		expanded, err := thread(e, list)
+		if err != nil {
+			return nil, err
+		}
+		if e.print {
+			e.logMacroExpansion(list.V[0].String())
+		}
+		return e.expand(expanded)
+	}
 	for i, expr := range list.V {
 		switch expr := expr.(type) {
 		case *ex.List:
@@ -154,6 +192,7 @@ func (e *Expander) expandCall(list *ex.List) (ex.Expr, *er.Error) {
 			var expanded ex.Expr
 			var err *er.Error
 			if list.IsHead(expr) {
+				macro = e.findOverload(macro, list)
 				expanded, err = e.expandMacro(macro, list)
 				if err != nil {
 					return nil, err
@@ -271,6 +310,40 @@ func (e *Expander) findMacro(name string) (*ex.Macro, bool) {
 	return nil, false
 }
 
+// The threading macro at the head of list,
+// unless a user macro of the same name exists.
+func (e *Expander) findThreading(list *ex.List) (func(*Expander, *ex.List) (ex.Expr, *er.Error), bool) {
+	hd, ok := list.Head().(ex.Identifier)
+	if !ok {
+		return nil, false
+	}
+	if _, ok := e.findMacro(hd.V); ok {
+		return nil, false
+	}
+	thread, ok := threading[hd.V]
+	return thread, ok
+}
+
+// Macros can be overloaded on the shape of their
+// parameters, the first one that fits is used.
+//
+//	(macro for [[i start end next] body] ...)
+//	(macro for [[x xs] body] ...)
+func (e *Expander) findOverload(macro *ex.Macro, list *ex.List) *ex.Macro {
+	for _, m := range e.macros {
+		if m.Name != macro.Name {
+			continue
+		}
+		if len(m.Params.V) != len(list.V)-1 && !m.Params.HasAmpersand() {
+			continue
+		}
+		if _, err := macroReplacementArgs(m.Params, list); err == nil {
+			return m
+		}
+	}
+	return macro
+}
+
 func (e *Expander) expandMacro(m *ex.Macro, list *ex.List) (ex.Expr, *er.Error) {
 	pos := list.P
 	if len(m.Params.V) != len(list.V)-1 && !m.Params.HasAmpersand() {
@@ -377,7 +450,7 @@ func macroReplacementArgs(params *ex.Vec, args *ex.List) (map[string]ex.Expr, *e
 			// for i, arg := range args.V[i+1:] {
 			// 	nlist.V[i] = &ex.Quote{E: arg, P: arg.Pos()}
 			// }
-			nargs[param.V.V] = &ex.List{V: args.V[i+1:]}
+			nargs[param.V.String()] = &ex.List{V: args.V[i+1:]}
 			return nargs, nil
 		default:
 			nargs[param.String()] = arg
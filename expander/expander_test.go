@@ -1,6 +1,7 @@
 package expander
 
 import (
+	"os/exec"
 	"strings"
 	"testing"
 
@@ -18,7 +19,11 @@ func TestExpander(t *testing.T) {
 	}{
 		{
 			input:  "'(1 2 3)",
-			output: "(1 2 3)",
+			output: "'(1 2 3)",
+		},
+		{
+			input:  "(macro m [x] `(+ ,x 1)) '(m 2)",
+			output: "(macro m [x] `(+ ,x 1)) '(m 2)",
 		},
 		{
 			input:  "`(1 2 3)",
@@ -45,10 +50,78 @@ func TestExpander(t *testing.T) {
 			input:  "(macro inc-two [[x y]] `[(+ ,x 1) (+ ,y 1)]) (inc-two [1 4])",
 			output: "(macro inc-two [[x y]] `[(+ ,x 1) (+ ,y 1)]) [(+ 1 1) (+ 4 1)]",
 		},
+		{
+			input:  "(macro m [[a b] c] `(+ ,a ,b ,c)) (macro m [[a] c] `(- ,a ,c)) (m [1] 2) (m [1 2] 3)",
+			output: "(macro m [[a b] c] `(+ ,a ,b ,c)) (macro m [[a] c] `(- ,a ,c)) (- 1 2) (+ 1 2 3)",
+		},
 		{
 			input:  "(macro id [& x] x) (each [x (id 1 2 3)] (println x))",
 			output: "(macro id [& x] x) (each [x (1 2 3)] (println x))",
 		},
+		{
+			input:  "(macro twice [f x] `(,f (,f ,x))) (twice - 5)",
+			output: "(macro twice [f x] `(,f (,f ,x))) (- (- 5))",
+		},
+		{
+			input:  "(macro sorted? [& xs] (. < (apply null xs))) (sorted? 1 2 3)",
+			output: "(macro sorted? [& xs] (. < (apply null xs))) true",
+		},
+		{
+			input:  "(-> [1 2 3] (get 2) (println))",
+			output: "(println (get [1 2 3] 2))",
+		},
+		{
+			input:  "(->> [1 2 3] (map (fn [x] (+ x 1))) (println))",
+			output: "(println (map (fn [x] (+ x 1)) [1 2 3]))",
+		},
+		{
+			input:  "(-> 5 - (* 2))",
+			output: "(* (- 5) 2)",
+		},
+		{
+			input:  "(->> 5 (- 1) -)",
+			output: "(- (- 1 5))",
+		},
+		{
+			input:  "(-> x inc str :a (fn [y] y))",
+			output: "((fn [y] y) (:a (str (inc x))))",
+		},
+		{
+			input:  "(macro twice [x] `(* ,x 2)) (-> 1 twice)",
+			output: "(macro twice [x] `(* ,x 2)) (* 1 2)",
+		},
+		{
+			input:  "(-> x (->> (f 1)))",
+			output: "(f 1 x)",
+		},
+		{
+			input:  "(as-> 1 n (+ n 1) (f 2 n))",
+			output: "(do (var __thread1 1) (do (var n __thread1) (set n (+ n 1)) (set n (f 2 n)) n))",
+		},
+		{
+			input:  "(fn f [n] (as-> (+ n 1) n (* n 2)))",
+			output: "(fn f [n] (do (var __thread1 (+ n 1)) (do (var n __thread1) (set n (* n 2)) n)))",
+		},
+		{
+			input:  "(some-> x :a (f 1))",
+			output: "(do (var __thread1 x) (when (!= __thread1 nil) (set __thread1 (:a __thread1))) (when (!= __thread1 nil) (set __thread1 (f __thread1 1))) __thread1)",
+		},
+		{
+			input:  "(some->> x (f 1))",
+			output: "(do (var __thread1 x) (when (!= __thread1 nil) (set __thread1 (f 1 __thread1))) __thread1)",
+		},
+		{
+			input:  "(cond-> x a f b (g 1))",
+			output: "(do (var __thread1 x) (when a (set __thread1 (f __thread1))) (when b (set __thread1 (g __thread1 1))) __thread1)",
+		},
+		{
+			input:  "(cond->> x a (g 1))",
+			output: "(do (var __thread1 x) (when a (set __thread1 (g 1 __thread1))) __thread1)",
+		},
+		{
+			input:  "(doto (f) (g 1) h)",
+			output: "(do (var __thread1 (f)) (g __thread1 1) (h __thread1) __thread1)",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
@@ -61,6 +134,52 @@ func TestExpander(t *testing.T) {
 	}
 }
 
+func TestThreadingError(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "(->)",
+			output: "-> requires an expression",
+		},
+		{
+			input:  "(-> x 1)",
+			output: "expected function or list: 1",
+		},
+		{
+			input:  "(as-> x 1)",
+			output: "expected identifier: 1",
+		},
+		{
+			input:  "(cond-> x a)",
+			output: "cond-> requires pairs of tests and steps",
+		},
+	}
+	rt := newRuntime(t)
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			lexer := lexer.New()
+			tokens, erre := lexer.LexString(tt.input)
+			if erre != nil {
+				t.Fatal(erre)
+			}
+			parser := parser.New(lexer)
+			exprs, erre := parser.Parse(tokens)
+			if erre != nil {
+				t.Fatal(erre)
+			}
+			_, erre = New(lexer, parser, compiler.New(), rt).Expand(exprs, false)
+			if erre == nil {
+				t.Fatalf("expected error %q, got nil", tt.output)
+			}
+			if erre.Msg != tt.output {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n", tt.output, erre.Msg)
+			}
+		})
+	}
+}
+
 func getCode(t *testing.T, input string) string {
 	bb := []byte(input)
 	lexer := lexer.New()
@@ -73,10 +192,7 @@ func getCode(t *testing.T, input string) string {
 	if erre != nil {
 		t.Fatalf("\n\n%s:\n\n%v\n\n", h.Bold("parse error"), erre.String(bb))
 	}
-	rt, err := runtime.New()
-	if err != nil {
-		t.Fatal(err)
-	}
+	rt := newRuntime(t)
 	exprs, erre = New(lexer, parser, compiler.New(), rt).Expand(exprs, false)
 	if erre != nil {
 		t.Fatal(erre)
@@ -90,3 +206,18 @@ func getCode(t *testing.T, input string) string {
 	}
 	return s.String()
 }
+
+// A runtime for the test, which is stopped when
+// the test is done. Tests are skipped without Deno.
+func newRuntime(t *testing.T) *runtime.Runtime {
+	t.Helper()
+	if _, err := exec.LookPath("deno"); err != nil {
+		t.Skip("deno is not installed")
+	}
+	rt, err := runtime.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(rt.Close)
+	return rt
+}
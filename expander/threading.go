@@ -0,0 +1,198 @@
+package expander
+
+import (
+	"fmt"
+
+	er "github.com/fholmqvist/remlisp/err"
+	ex "github.com/fholmqvist/remlisp/expr"
+	"github.com/fholmqvist/remlisp/token/operator"
+)
+
+// Threading macros, which are expanded here rather
+// than in the runtime as they rewrite their steps.
+var threading = map[string]func(*Expander, *ex.List) (ex.Expr, *er.Error){
+	"->":      (*Expander).threadFirst,
+	"->>":     (*Expander).threadLast,
+	"as->":    (*Expander).threadAs,
+	"some->":  (*Expander).threadSome,
+	"some->>": (*Expander).threadSome,
+	"cond->":  (*Expander).threadCond,
+	"cond->>": (*Expander).threadCond,
+	"doto":    (*Expander).doto,
+}
+
+// Threads x as the first argument of every step.
+//
+//	(-> x f (g 1)) => (g (f x) 1)
+func (e *Expander) threadFirst(list *ex.List) (ex.Expr, *er.Error) {
+	return e.thread(list, false)
+}
+
+// Threads x as the last argument of every step.
+//
+//	(->> x f (g 1)) => (g 1 (f x))
+func (e *Expander) threadLast(list *ex.List) (ex.Expr, *er.Error) {
+	return e.thread(list, true)
+}
+
+func (e *Expander) thread(list *ex.List, last bool) (ex.Expr, *er.Error) {
+	if len(list.V) < 2 {
+		return nil, errAt(list, "%s requires an expression", list.V[0])
+	}
+	x := list.V[1]
+	for _, step := range list.V[2:] {
+		next, err := threadStep(step, x, last)
+		if err != nil {
+			return nil, err
+		}
+		x = next
+	}
+	return x, nil
+}
+
+// Binds x to name, which every step is bound to in turn.
+// x is evaluated before name is bound, as it may use
+// another binding of the same name.
+//
+//	(as-> x n (f n 1)) => (do (var t x) (do (var n t) (set n (f n 1)) n))
+func (e *Expander) threadAs(list *ex.List) (ex.Expr, *er.Error) {
+	if len(list.V) < 3 {
+		return nil, errAt(list, "as-> requires an expression and a name")
+	}
+	name, ok := list.V[2].(ex.Identifier)
+	if !ok {
+		return nil, errAt(list.V[2], "expected identifier: %s", list.V[2])
+	}
+	tmp := e.gensym(list)
+	do := block(list, name, tmp)
+	for _, step := range list.V[3:] {
+		do.Append(form(step, "set", name, step))
+	}
+	do.Append(name)
+	outer := block(list, tmp, list.V[1])
+	outer.Append(do)
+	return outer, nil
+}
+
+// Like -> and ->>, but stops at the first nil.
+//
+//	(some-> x f g) => (do (var t x) (when (!= t nil) (set t (f t))) ... t)
+func (e *Expander) threadSome(list *ex.List) (ex.Expr, *er.Error) {
+	if len(list.V) < 2 {
+		return nil, errAt(list, "%s requires an expression", list.V[0])
+	}
+	last := list.V[0].String() == "some->>"
+	tmp := e.gensym(list)
+	do := block(list, tmp, list.V[1])
+	for _, step := range list.V[2:] {
+		next, err := threadStep(step, tmp, last)
+		if err != nil {
+			return nil, err
+		}
+		neq := ex.Op{Op: operator.NEQ, P: step.Pos()}
+		notNil := &ex.List{V: []ex.Expr{neq, tmp, ex.Nil{P: step.Pos()}}, P: step.Pos()}
+		do.Append(form(step, "when", notNil, form(step, "set", tmp, next)))
+	}
+	do.Append(tmp)
+	return do, nil
+}
+
+// Like -> and ->>, but only through the steps
+// whose test is truthy. Tests aren't threaded.
+//
+//	(cond-> x a f b g) => (do (var t x) (when a (set t (f t))) (when b (set t (g t))) t)
+func (e *Expander) threadCond(list *ex.List) (ex.Expr, *er.Error) {
+	if len(list.V) < 2 {
+		return nil, errAt(list, "%s requires an expression", list.V[0])
+	}
+	clauses := list.V[2:]
+	if len(clauses)%2 != 0 {
+		return nil, errAt(list, "%s requires pairs of tests and steps", list.V[0])
+	}
+	last := list.V[0].String() == "cond->>"
+	tmp := e.gensym(list)
+	do := block(list, tmp, list.V[1])
+	for i := 0; i < len(clauses); i += 2 {
+		test, step := clauses[i], clauses[i+1]
+		next, err := threadStep(step, tmp, last)
+		if err != nil {
+			return nil, err
+		}
+		do.Append(form(step, "when", test, form(step, "set", tmp, next)))
+	}
+	do.Append(tmp)
+	return do, nil
+}
+
+// Calls every step with x first, for side
+// effects, and returns x.
+//
+//	(doto x (f 1) g) => (do (var t x) (f t 1) (g t) t)
+func (e *Expander) doto(list *ex.List) (ex.Expr, *er.Error) {
+	if len(list.V) < 2 {
+		return nil, errAt(list, "doto requires an expression")
+	}
+	tmp := e.gensym(list)
+	do := block(list, tmp, list.V[1])
+	for _, step := range list.V[2:] {
+		next, err := threadStep(step, tmp, false)
+		if err != nil {
+			return nil, err
+		}
+		do.Append(next)
+	}
+	do.Append(tmp)
+	return do, nil
+}
+
+// Inserts x into a step, which is a list, or a
+// function that is called with only x.
+//
+//	x, (f 1) => (f x 1) or (f 1 x)
+//	x, f     => (f x)
+func threadStep(step, x ex.Expr, last bool) (ex.Expr, *er.Error) {
+	switch step := step.(type) {
+	case *ex.List:
+		if len(step.V) == 0 {
+			return nil, errAt(step, "expected function: %s", step)
+		}
+		v := append([]ex.Expr{}, step.V...)
+		if last {
+			v = append(v, x)
+		} else {
+			v = append(v[:1], append([]ex.Expr{x}, v[1:]...)...)
+		}
+		return &ex.List{V: v, P: step.P}, nil
+	case ex.Identifier, ex.Op, ex.Atom, *ex.AnonymousFn:
+		return &ex.List{V: []ex.Expr{step, x}, P: step.Pos()}, nil
+	}
+	return nil, errAt(step, "expected function or list: %s", step)
+}
+
+// A temporary name for the value being threaded.
+func (e *Expander) gensym(list *ex.List) ex.Identifier {
+	e.gensyms++
+	return ex.Identifier{V: fmt.Sprintf("__thread%d", e.gensyms), P: list.P}
+}
+
+// A do block that starts by binding name to x.
+func block(list *ex.List, name ex.Identifier, x ex.Expr) *ex.List {
+	return form(list, "do", form(list, "var", name, x))
+}
+
+// A special form at the position of at.
+//
+//	form(x, "set", a, b) => (set a b)
+func form(at ex.Expr, head string, args ...ex.Expr) *ex.List {
+	hd := ex.Identifier{V: head, P: at.Pos()}
+	return &ex.List{V: append([]ex.Expr{hd}, args...), P: at.Pos()}
+}
+
+func errAt(expr ex.Expr, format string, args ...any) *er.Error {
+	pos := expr.Pos()
+	return &er.Error{
+		Msg:   fmt.Sprintf(format, args...),
+		Start: pos.Start,
+		End:   pos.End,
+	}
+}
@@ -301,6 +301,9 @@ type Fn struct {
 	Params    *Vec
 	DocString string
 	Body      Expr
+	Arities   []Arity
+	Async     bool
+	Generator bool
 	P         tk.Position
 }
 
@@ -308,9 +311,19 @@ func (Fn) Expr() {}
 
 func (f Fn) String() string {
 	var s strings.Builder
-	s.WriteString("(fn ")
+	s.WriteByte('(')
+	s.WriteString(f.keyword())
+	s.WriteByte(' ')
 	s.WriteString(f.Name)
 	s.WriteString(" ")
+	if len(f.Arities) > 0 {
+		if f.DocString != "" {
+			s.WriteString(fmt.Sprintf("%s ", f.DocString))
+		}
+		s.WriteString(aritiesString(f.Arities))
+		s.WriteByte(')')
+		return s.String()
+	}
 	s.WriteString(f.Params.String())
 	s.WriteString(" ")
 	if f.DocString != "" {
@@ -325,17 +338,57 @@ func (f Fn) Pos() tk.Position {
 	return f.P
 }
 
+func (f Fn) keyword() string {
+	if f.Async {
+		return "async-fn"
+	} else if f.Generator {
+		return "gen-fn"
+	}
+	return "fn"
+}
+
+func (f Fn) ToList() *List {
+	l := &List{P: f.P}
+	l.Append(Identifier{V: f.keyword(), P: f.P})
+	l.Append(Identifier{V: f.Name, P: f.P})
+	if len(f.Arities) > 0 {
+		if f.DocString != "" {
+			l.Append(String{V: strings.Trim(f.DocString, `"`), P: f.P})
+		}
+		for _, a := range f.Arities {
+			l.Append(a.ToList())
+		}
+		return l
+	}
+	l.Append(f.Params)
+	if f.DocString != "" {
+		l.Append(String{V: strings.Trim(f.DocString, `"`), P: f.P})
+	}
+	l.Append(f.Body)
+	return l
+}
+
 type AnonymousFn struct {
-	Params *Vec
-	Body   Expr
-	P      tk.Position
+	Params    *Vec
+	Body      Expr
+	Arities   []Arity
+	Async     bool
+	Generator bool
+	P         tk.Position
 }
 
 func (AnonymousFn) Expr() {}
 
 func (f AnonymousFn) String() string {
 	var s strings.Builder
-	s.WriteString("(fn ")
+	s.WriteByte('(')
+	s.WriteString(f.keyword())
+	s.WriteByte(' ')
+	if len(f.Arities) > 0 {
+		s.WriteString(aritiesString(f.Arities))
+		s.WriteByte(')')
+		return s.String()
+	}
 	s.WriteString(f.Params.String())
 	s.WriteString(" ")
 	s.WriteString(f.Body.String())
@@ -347,8 +400,62 @@ func (f AnonymousFn) Pos() tk.Position {
 	return f.P
 }
 
+func (f AnonymousFn) keyword() string {
+	if f.Async {
+		return "async-fn"
+	} else if f.Generator {
+		return "gen-fn"
+	}
+	return "fn"
+}
+
+func (f AnonymousFn) ToList() *List {
+	l := &List{P: f.P}
+	l.Append(Identifier{V: f.keyword(), P: f.P})
+	if len(f.Arities) > 0 {
+		for _, a := range f.Arities {
+			l.Append(a.ToList())
+		}
+		return l
+	}
+	l.Append(f.Params)
+	l.Append(f.Body)
+	return l
+}
+
+// Arity is one of the bodies of a multi-arity
+// function, picked by the number of arguments.
+//
+//	(fn area ([r] ...) ([w h] ...))
+type Arity struct {
+	Params *Vec
+	Body   Expr
+	P      tk.Position
+}
+
+func (a Arity) String() string {
+	return fmt.Sprintf("(%s %s)", a.Params, a.Body)
+}
+
+func (a Arity) ToList() *List {
+	return &List{V: []Expr{a.Params, a.Body}, P: a.P}
+}
+
+func aritiesString(arities []Arity) string {
+	ss := make([]string, len(arities))
+	for i, a := range arities {
+		ss[i] = a.String()
+	}
+	return strings.Join(ss, " ")
+}
+
+// VariableArg is the rest of the arguments, either
+// as a vector or as keyword options.
+//
+//	[x & xs]
+//	[x & {:keys [verbose]}]
 type VariableArg struct {
-	V Identifier
+	V Expr
 	P tk.Position
 }
 
@@ -405,6 +512,15 @@ func (m Macro) Pos() tk.Position {
 	return m.P
 }
 
+func (m Macro) ToList() *List {
+	l := &List{P: m.P}
+	l.Append(Identifier{V: "macro", P: m.P})
+	l.Append(Identifier{V: m.Name, P: m.P})
+	l.Append(m.Params)
+	l.Append(m.Body)
+	return l
+}
+
 func (m Macro) AsFn() *Fn {
 	return &Fn{
 		Name:   m.Name,
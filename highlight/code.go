@@ -55,6 +55,15 @@ func code(str string, errorColor bool) string {
 			}
 			s.WriteString(Gray(s2.String()))
 			i++
+		case '#':
+			end := i + 1
+			if i+1 < len(str) && str[i+1] == '|' {
+				end = blockComment(str, i)
+			} else if i+1 < len(str) && str[i+1] == '_' {
+				end = discardedForm(str, i+2)
+			}
+			s.WriteString(Gray(str[i:end]))
+			i = end
 		default:
 			var s2 strings.Builder
 			for i < len(str) && !isDelimiter(str[i]) {
@@ -105,11 +114,74 @@ func code(str string, errorColor bool) string {
 	return s.String()
 }
 
+// Returns the index after the end of the (nested)
+// block comment starting at i.
+func blockComment(str string, i int) int {
+	depth := 0
+	for i < len(str) {
+		if str[i] == '#' && i+1 < len(str) && str[i+1] == '|' {
+			depth++
+			i++
+		} else if str[i] == '|' && i+1 < len(str) && str[i+1] == '#' {
+			depth--
+			i++
+		}
+		i++
+		if depth == 0 {
+			break
+		}
+	}
+	return i
+}
+
+// Returns the index after the end of the
+// form following a #_ at i.
+func discardedForm(str string, i int) int {
+	for i < len(str) && (str[i] == ' ' || str[i] == '\n' || str[i] == '\t') {
+		i++
+	}
+	depth := 0
+	for i < len(str) {
+		switch str[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+			if depth < 0 {
+				return i
+			}
+		case '"':
+			i++
+			for i < len(str) && str[i] != '"' {
+				i++
+			}
+		case '#':
+			if i+1 < len(str) && str[i+1] == '|' {
+				i = blockComment(str, i)
+				continue
+			}
+		default:
+			if depth == 0 && isDelimiter(str[i]) {
+				return i
+			}
+		}
+		i++
+		if depth == 0 && i > 0 && isClosing(str[i-1]) {
+			return i
+		}
+	}
+	return i
+}
+
+func isClosing(b byte) bool {
+	return b == ')' || b == ']' || b == '}' || b == '"'
+}
+
 func isPurple(s string) bool {
 	s = strings.TrimSpace(s)
 	switch s {
-	case "fn", "if", "cond", "case", "match", "while", "break", "continue", "var",
-		"set", "get", "macro":
+	case "fn", "async-fn", "async", "await", "gen-fn", "yield", "loop", "recur", "if", "when", "unless", "cond", "case", "match", "while", "break", "continue", "var",
+		"set", "set!", "new", "js*", "get", "macro", "try", "catch", "finally", "throw":
 		return true
 	default:
 		return false
@@ -114,6 +114,18 @@ func TestCode(t *testing.T) {
 			input:    Code("; hello"),
 			expected: Gray("; hello"),
 		},
+		{
+			input:    Code("#| hello #| nested |# |#1"),
+			expected: Gray("#| hello #| nested |# |#") + Green("1"),
+		},
+		{
+			input:    Code("#_ (hello \")\") 1"),
+			expected: Gray("#_ (hello \")\")") + " " + Green("1"),
+		},
+		{
+			input:    Code("(#_ hello)"),
+			expected: Blue("(") + Gray("#_ hello") + Blue(")"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
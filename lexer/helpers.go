@@ -38,7 +38,7 @@ func isNumber(b, b2 byte) bool {
 }
 
 func isSpace(b byte) bool {
-	return b == ' '
+	return b == ' ' || b == '\t' || b == '\r'
 }
 
 func isNewLine(b byte) bool {
@@ -66,10 +66,22 @@ func isComment(b byte) bool {
 	return b == ';'
 }
 
+func isHash(b byte) bool {
+	return b == '#'
+}
+
+func isPipe(b byte) bool {
+	return b == '|'
+}
+
 func isUnderscore(b byte) bool {
 	return b == '_'
 }
 
+func isPercent(b byte) bool {
+	return b == '%'
+}
+
 func isOperator(b byte) bool {
 	switch b {
 	case '+', '-', '*', '/', '%', '=', '<', '>', '!':
@@ -87,6 +99,8 @@ func isComplexOperator(a, b byte) bool {
 		return true
 	case a == '>' && b == '=':
 		return true
+	case a == '*' && b == '*':
+		return true
 	default:
 		return false
 	}
@@ -154,7 +168,7 @@ func isAtSign(b byte) bool {
 
 func isDelimiter(b byte) bool {
 	switch b {
-	case ' ', ',', ':', '\n', '\t', '[', ']', '(', ')', '{', '}':
+	case ' ', ',', ':', '\n', '\t', '\r', '[', ']', '(', ')', '{', '}':
 		return true
 	default:
 		return false
@@ -3,6 +3,7 @@ package lexer
 import (
 	"fmt"
 	"strconv"
+	"unicode"
 
 	e "github.com/fholmqvist/remlisp/err"
 	h "github.com/fholmqvist/remlisp/highlight"
@@ -65,6 +66,21 @@ func (l *Lexer) lex() (tk.Token, *e.Error) {
 			l.step()
 		}
 		return l.lex()
+	case isHash(l.ch) && isPipe(p):
+		if err := l.lexBlockComment(); err != nil {
+			return nil, err
+		}
+		return l.lex()
+	case isHash(l.ch) && isUnderscore(p):
+		l.step()
+		l.step()
+		return tk.Discard{P: l.Pos()}, nil
+	case isHash(l.ch) && isLeftParens(p):
+		l.step()
+		l.step()
+		return tk.FnShorthand{P: l.Pos()}, nil
+	case isPercent(l.ch) && (unicode.IsNumber(rune(p)) || isAmpersand(p)):
+		return l.lexIdent()
 	case isOperator(l.ch):
 		return l.lexOperator()
 	case isComma(l.ch):
@@ -223,3 +239,28 @@ func (l *Lexer) lexOperator() (tk.Token, *e.Error) {
 		P: l.Pos(),
 	}, nil
 }
+
+// Skips a block comment, which may be nested.
+//
+//	#| outer #| inner |# still outer |#
+func (l *Lexer) lexBlockComment() *e.Error {
+	depth := 0
+	for l.inRange() {
+		p := l.peek()
+		switch {
+		case isHash(l.ch) && isPipe(p):
+			depth++
+			l.step()
+		case isPipe(l.ch) && isHash(p):
+			depth--
+			l.step()
+		}
+		l.step()
+		if depth == 0 {
+			return nil
+		}
+	}
+	pos := l.Pos()
+	return e.FromPosition(pos, fmt.Sprintf("%s %s",
+		h.Bold(pos.String()), h.Red("unterminated block comment")))
+}
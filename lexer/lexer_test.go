@@ -71,6 +71,18 @@ func TestLexer(t *testing.T) {
 			input:  ";; comments are ignored",
 			output: "",
 		},
+		{
+			input:  "#| block comments are ignored |#",
+			output: "",
+		},
+		{
+			input:  "#| block #| comments |# nest |#",
+			output: "",
+		},
+		{
+			input:  "#|\nmultiline\n|# 1",
+			output: "1",
+		},
 		{input: "(", output: "("},
 		{input: ")", output: ")"},
 		{input: "[", output: "["},
@@ -87,11 +99,19 @@ func TestLexer(t *testing.T) {
 		{input: "!=", output: "!="},
 		{input: "<=", output: "<="},
 		{input: ">=", output: ">="},
+		{input: "**", output: "**"},
+		{input: "quot", output: "quot"},
+		{input: "bit-and", output: "bit-and"},
 		{input: " 1", output: "1"},
+		{input: "\t1\r\n", output: "1"},
 		{input: ".", output: "."},
 		{input: "&", output: "&"},
 		{input: "'", output: "'"},
 		{input: "`", output: "`"},
+		{input: "#_", output: "#_"},
+		{input: "#(", output: "#("},
+		{input: "%1", output: "%1"},
+		{input: "%&", output: "%&"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
@@ -115,3 +135,18 @@ func TestLexer(t *testing.T) {
 		})
 	}
 }
+
+func TestLexerError(t *testing.T) {
+	tests := []string{
+		"#| unterminated",
+		"#| #| nested |#",
+		"#",
+	}
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			if _, err := New().LexString(tt); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	e "github.com/fholmqvist/remlisp/err"
 	ex "github.com/fholmqvist/remlisp/expr"
@@ -19,6 +20,17 @@ func (p *Parser) next() (tk.Token, *e.Error) {
 	return t, nil
 }
 
+// Like parse, but skips over discarded forms
+// so that a nil expression is never returned.
+func (p *Parser) parseNext() (ex.Expr, *e.Error) {
+	for {
+		expr, err := p.parse()
+		if err != nil || expr != nil {
+			return expr, err
+		}
+	}
+}
+
 func (p Parser) inRange() bool {
 	return p.i < len(p.tokens)
 }
@@ -130,3 +142,36 @@ func (p Parser) errGot(expr ex.Expr, msg string, code string) *e.Error {
 		End:   pos.End,
 	}
 }
+
+// The threading macros of the expander, whose
+// steps are incomplete forms until expanded.
+//
+//	(-> xs (get 0)) => (get xs 0)
+func isThreading(s string) bool {
+	switch s {
+	case "->", "->>", "as->", "some->", "some->>", "cond->", "cond->>", "doto":
+		return true
+	}
+	return false
+}
+
+// Patterns without wildcards are compared
+// structurally, otherwise element by element.
+//
+//	[1 2] => (= x [1 2])
+//	[_ 2] => (and (= (length x) 2) (= 2 (get x 1)))
+func matchPattern(cond ex.Expr, pattern []ex.Expr) string {
+	var s strings.Builder
+	wildcard := false
+	for i, expr := range pattern {
+		if expr.String() == "_" {
+			wildcard = true
+			continue
+		}
+		s.WriteString(fmt.Sprintf(" (= %s (get %s %d))", expr, cond, i))
+	}
+	if !wildcard {
+		return fmt.Sprintf("(= %s %s)", cond, &ex.Vec{V: pattern})
+	}
+	return fmt.Sprintf("(and (= (length %s) %d)%s)", cond, len(pattern), s.String())
+}
@@ -3,10 +3,12 @@ package parser
 import (
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 
 	e "github.com/fholmqvist/remlisp/err"
 	ex "github.com/fholmqvist/remlisp/expr"
+	h "github.com/fholmqvist/remlisp/highlight"
 	"github.com/fholmqvist/remlisp/lexer"
 	"github.com/fholmqvist/remlisp/parser/state"
 	tk "github.com/fholmqvist/remlisp/token"
@@ -105,6 +107,10 @@ func (p *Parser) parseExpr(next tk.Token) (ex.Expr, *e.Error) {
 		return p.parseQuasiquote(t)
 	case tk.Comma:
 		return p.parseUnquote(t)
+	case tk.Discard:
+		return p.parseDiscard()
+	case tk.FnShorthand:
+		return p.parseFnShorthand(t)
 	default:
 		return nil, p.errLastTokenType("unexpected token", next)
 	}
@@ -161,7 +167,7 @@ func (p *Parser) parseList() (ex.Expr, *e.Error) {
 			continue
 		}
 		estr := expr.String()
-		if estr == "->" || estr == "->>" {
+		if isThreading(estr) {
 			p.setState(state.THREADING)
 			statesSet++
 		}
@@ -182,30 +188,48 @@ func (p *Parser) parseList() (ex.Expr, *e.Error) {
 		list.Last().Pos().BumpRight(),
 	)
 	switch hd.String() {
-	case "fn":
+	case "fn", "async-fn", "gen-fn":
 		return p.parseFn(list)
+	case "async":
+		return p.parseAsync(list)
+	case "await":
+		return p.parseAwait(list)
+	case "yield":
+		return p.parseYield(list)
+	case "loop":
+		return p.parseLoop(list)
 	case "if":
 		return p.parseIf(list)
+	case "when", "unless":
+		return p.parseWhen(list)
+	case "cond":
+		return p.parseCond(list)
+	case "case":
+		return p.parseCase(list)
 	case "while":
 		return p.parseWhile(list)
 	case "do":
 		return p.parseDo(list)
 	case "var":
 		return p.parseVar(list)
-	case "set":
+	case "set", "set!":
 		return p.parseSet(list)
 	case "get":
 		return p.parseGet(list)
 	case ".":
 		return p.parseDotList(list)
+	case "new":
+		return p.parseNew(list)
+	case "js*":
+		return p.parseRawJS(list)
 	case "macro":
 		return p.parseMacro(list)
 	case "match":
 		return p.parseMatch(list)
-	case "->":
-		return p.parseThreadFirst(list)
-	case "->>":
-		return p.parseThreadLast(list)
+	case "try":
+		return p.parseTry(list)
+	case "throw":
+		return p.parseThrow(list)
 	default:
 		return list, nil
 	}
@@ -216,12 +240,18 @@ func (p *Parser) parseFn(list *ex.List) (ex.Expr, *e.Error) {
 	fn := list.Pop()
 	name, actual, ok := list.PopIdentifier()
 	if !ok {
-		if _, ok := actual.(*ex.Vec); ok {
+		switch actual.(type) {
+		case *ex.Vec, *ex.List:
 			anonymous = true
-		} else {
+		default:
 			return nil, p.errLastTokenType("expected identifier", actual)
 		}
 	}
+	async := fn.String() == "async-fn"
+	generator := fn.String() == "gen-fn"
+	if isArities(list) {
+		return p.parseArities(list, fn, name, anonymous, async, generator)
+	}
 	params, actual, ok := list.PopVec()
 	if !ok {
 		return nil, p.errLastTokenType("expected parameters", actual)
@@ -237,9 +267,11 @@ func (p *Parser) parseFn(list *ex.List) (ex.Expr, *e.Error) {
 	}
 	if anonymous {
 		return &ex.AnonymousFn{
-			Params: params,
-			Body:   body,
-			P:      tk.Between(fn.Pos().BumpLeft(), body.Pos().BumpRight()),
+			Params:    params,
+			Body:      body,
+			Async:     async,
+			Generator: generator,
+			P:         tk.Between(fn.Pos().BumpLeft(), body.Pos().BumpRight()),
 		}, nil
 	} else {
 		return &ex.Fn{
@@ -247,14 +279,194 @@ func (p *Parser) parseFn(list *ex.List) (ex.Expr, *e.Error) {
 			Params:    params,
 			DocString: docstring,
 			Body:      body,
+			Async:     async,
+			Generator: generator,
 			P:         tk.Between(fn.Pos().BumpLeft(), body.Pos().BumpRight()),
 		}, nil
 	}
 }
 
+// Whether the rest of a function are arities,
+// optionally preceded by a docstring.
+//
+//	(fn area "Area of a circle or rectangle."
+//	  ([r] ...)
+//	  ([w h] ...))
+func isArities(list *ex.List) bool {
+	rest := list.V
+	if len(rest) > 0 {
+		if _, ok := rest[0].(ex.String); ok {
+			rest = rest[1:]
+		}
+	}
+	if len(rest) == 0 {
+		return false
+	}
+	_, ok := rest[0].(*ex.List)
+	return ok
+}
+
+func (p *Parser) parseArities(list *ex.List, fn ex.Expr, name ex.Identifier, anonymous, async, generator bool) (ex.Expr, *e.Error) {
+	var docstring string
+	if s, ok := list.V[0].(ex.String); ok {
+		docstring = s.String()
+		list.Pop()
+	}
+	arities := []ex.Arity{}
+	counts := map[int]bool{}
+	variadic := false
+	for _, expr := range list.V {
+		alist, ok := expr.(*ex.List)
+		if !ok {
+			return nil, p.errWas(expr, "expected arity", expr)
+		}
+		if len(alist.V) != 2 {
+			return nil, p.errGot(alist, "arity requires parameters and one expression", alist.String())
+		}
+		params, ok := alist.V[0].(*ex.Vec)
+		if !ok {
+			return nil, p.errWas(alist.V[0], "expected parameters", alist.V[0])
+		}
+		if params.HasAmpersand() {
+			if variadic {
+				return nil, p.errGot(alist, "only one arity can be variadic", alist.String())
+			}
+			variadic = true
+		} else {
+			if counts[len(params.V)] {
+				return nil, p.errGot(alist, "duplicate arity", alist.String())
+			}
+			counts[len(params.V)] = true
+		}
+		arities = append(arities, ex.Arity{
+			Params: params,
+			Body:   alist.V[1],
+			P:      alist.P,
+		})
+	}
+	pos := tk.Between(fn.Pos().BumpLeft(), list.Last().Pos().BumpRight())
+	if anonymous {
+		return &ex.AnonymousFn{
+			Arities:   arities,
+			Async:     async,
+			Generator: generator,
+			P:         pos,
+		}, nil
+	}
+	return &ex.Fn{
+		Name:      name.V,
+		DocString: docstring,
+		Arities:   arities,
+		Async:     async,
+		Generator: generator,
+		P:         pos,
+	}, nil
+}
+
+// Turns a function into an async function.
+//
+//	(async (fn [] ...)) => (async-fn [] ...)
+func (p *Parser) parseAsync(list *ex.List) (ex.Expr, *e.Error) {
+	if len(list.V) != 2 {
+		return nil, p.errGot(list, "async requires one function", list.String())
+	}
+	switch fn := list.V[1].(type) {
+	case *ex.Fn:
+		if fn.Generator {
+			return nil, p.errGot(list, "async generators are not supported", list.String())
+		}
+		fn.Async = true
+		return fn, nil
+	case *ex.AnonymousFn:
+		if fn.Generator {
+			return nil, p.errGot(list, "async generators are not supported", list.String())
+		}
+		fn.Async = true
+		return fn, nil
+	default:
+		return nil, p.errWas(fn, "expected function", fn)
+	}
+}
+
+func (p *Parser) parseAwait(list *ex.List) (ex.Expr, *e.Error) {
+	if len(list.V) != 2 && p.state != state.THREADING {
+		return nil, p.errGot(list, "await requires one expression", list.String())
+	}
+	return list, nil
+}
+
+func (p *Parser) parseYield(list *ex.List) (ex.Expr, *e.Error) {
+	if len(list.V) != 2 && p.state != state.THREADING {
+		return nil, p.errGot(list, "yield requires one expression", list.String())
+	}
+	return list, nil
+}
+
 func (p *Parser) parseIf(list *ex.List) (ex.Expr, *e.Error) {
-	if len(list.V) != 4 {
-		return nil, p.errGot(list, "if requires three expressions", list.String())
+	if len(list.V) != 3 && len(list.V) != 4 {
+		return nil, p.errGot(list, "if requires two or three expressions", list.String())
+	}
+	return list, nil
+}
+
+// Validates when and unless, which have an implicit do:
+//
+//	(when cond body...)
+func (p *Parser) parseWhen(list *ex.List) (ex.Expr, *e.Error) {
+	if len(list.V) < 3 && p.state != state.THREADING {
+		return nil, p.errGot(list,
+			fmt.Sprintf("%s requires a condition and a body", list.V[0]), list.String())
+	}
+	return list, nil
+}
+
+// Validates the cond form, which should look like:
+//
+//	(cond test expr
+//	      test expr
+//	      :else expr)
+func (p *Parser) parseCond(list *ex.List) (ex.Expr, *e.Error) {
+	if p.state == state.THREADING {
+		return list, nil
+	}
+	if len(list.V) < 3 || len(list.V)%2 == 0 {
+		return nil, p.errGot(list, "cond requires pairs of tests and expressions", list.String())
+	}
+	return list, nil
+}
+
+// Validates the case form, which should look like:
+//
+//	(case expr
+//	  1      "one"
+//	  :two   "two"
+//	  (3 4)  "three or four"
+//	  "default")
+func (p *Parser) parseCase(list *ex.List) (ex.Expr, *e.Error) {
+	if p.state == state.THREADING {
+		return list, nil
+	}
+	if len(list.V) < 3 {
+		return nil, p.errGot(list, "case requires an expression and clauses", list.String())
+	}
+	seen := map[string]bool{}
+	clauses := list.V[2:]
+	for i := 0; i+1 < len(clauses); i += 2 {
+		values := []ex.Expr{clauses[i]}
+		if l, ok := clauses[i].(*ex.List); ok {
+			values = l.V
+		}
+		for _, v := range values {
+			switch v.(type) {
+			case ex.Int, ex.Float, ex.String, ex.Bool, ex.Nil, ex.Atom:
+			default:
+				return nil, p.errWas(v, "case values must be literals or atoms", v)
+			}
+			if seen[v.String()] {
+				return nil, p.errGot(v, "duplicate case value", v.String())
+			}
+			seen[v.String()] = true
+		}
 	}
 	return list, nil
 }
@@ -276,6 +488,26 @@ func (p *Parser) parseDo(list *ex.List) (ex.Expr, *e.Error) {
 	return list, nil
 }
 
+// Validates the loop form, which should look like:
+//
+//	(loop [i 0 acc []] body)
+func (p *Parser) parseLoop(list *ex.List) (ex.Expr, *e.Error) {
+	if p.state == state.THREADING {
+		return list, nil
+	}
+	if len(list.V) != 3 {
+		return nil, p.errGot(list, "loop requires bindings and one expression", list.String())
+	}
+	bindings, ok := list.V[1].(*ex.Vec)
+	if !ok {
+		return nil, p.errWas(list.V[1], "expected bindings", list.V[1])
+	}
+	if len(bindings.V)%2 != 0 {
+		return nil, p.errGot(bindings, "expected value for binding", bindings.String())
+	}
+	return list, nil
+}
+
 func (p *Parser) parseVar(list *ex.List) (ex.Expr, *e.Error) {
 	if len(list.V) != 3 && p.state != state.THREADING {
 		return nil, p.errGot(list, "var requires two expressions", list.String())
@@ -285,7 +517,31 @@ func (p *Parser) parseVar(list *ex.List) (ex.Expr, *e.Error) {
 
 func (p *Parser) parseSet(list *ex.List) (ex.Expr, *e.Error) {
 	if len(list.V) != 3 && p.state != state.THREADING {
-		return nil, p.errGot(list, "set requires two expressions", list.String())
+		return nil, p.errGot(list,
+			fmt.Sprintf("%s requires two expressions", list.V[0]), list.String())
+	}
+	return list, nil
+}
+
+func (p *Parser) parseNew(list *ex.List) (ex.Expr, *e.Error) {
+	if len(list.V) < 2 && p.state != state.THREADING {
+		return nil, p.errGot(list, "new requires a class", list.String())
+	}
+	return list, nil
+}
+
+// Validates the raw JS form, which should look like:
+//
+//	(js* "~{} instanceof ~{}" x Map)
+func (p *Parser) parseRawJS(list *ex.List) (ex.Expr, *e.Error) {
+	if p.state == state.THREADING {
+		return list, nil
+	}
+	if len(list.V) < 2 {
+		return nil, p.errGot(list, "js* requires a template", list.String())
+	}
+	if _, ok := list.V[1].(ex.String); !ok {
+		return nil, p.errWas(list.V[1], "expected template string", list.V[1])
 	}
 	return list, nil
 }
@@ -297,6 +553,61 @@ func (p *Parser) parseGet(list *ex.List) (ex.Expr, *e.Error) {
 	return list, nil
 }
 
+// Validates the try form, which should look like:
+//
+//	(try body
+//	  (catch e handler)
+//	  (finally cleanup))
+//
+// where at least one of catch and finally is present.
+func (p *Parser) parseTry(list *ex.List) (ex.Expr, *e.Error) {
+	if p.state == state.THREADING {
+		return list, nil
+	}
+	if len(list.V) < 3 {
+		return nil, p.errGot(list, "try requires a body and catch or finally", list.String())
+	}
+	clauses := list.V[2:]
+	if len(clauses) > 2 {
+		return nil, p.errGot(list, "try accepts a single body expression", list.String())
+	}
+	for i, expr := range clauses {
+		clause, ok := expr.(*ex.List)
+		if !ok || len(clause.V) == 0 {
+			return nil, p.errWas(expr, "expected catch or finally", expr)
+		}
+		switch clause.V[0].String() {
+		case "catch":
+			if i > 0 {
+				return nil, p.errGot(clause, "catch must come before finally", clause.String())
+			}
+			if len(clause.V) != 3 {
+				return nil, p.errGot(clause, "catch requires a binding and one expression", clause.String())
+			}
+			if _, ok := clause.V[1].(ex.Identifier); !ok {
+				return nil, p.errWas(clause.V[1], "expected identifier", clause.V[1])
+			}
+		case "finally":
+			if i < len(clauses)-1 {
+				return nil, p.errGot(clause, "finally must come last", clause.String())
+			}
+			if len(clause.V) != 2 {
+				return nil, p.errGot(clause, "finally requires one expression", clause.String())
+			}
+		default:
+			return nil, p.errGot(clause, "expected catch or finally", clause.String())
+		}
+	}
+	return list, nil
+}
+
+func (p *Parser) parseThrow(list *ex.List) (ex.Expr, *e.Error) {
+	if len(list.V) != 2 && p.state != state.THREADING {
+		return nil, p.errGot(list, "throw requires one expression", list.String())
+	}
+	return list, nil
+}
+
 func (p *Parser) parseVec() (ex.Expr, *e.Error) {
 	vec := &ex.Vec{}
 	for p.inRange() && !p.is(tk.RightBracket{}) {
@@ -312,6 +623,12 @@ func (p *Parser) parseVec() (ex.Expr, *e.Error) {
 	if err := p.eat(tk.RightBracket{}); err != nil {
 		return nil, err
 	}
+	if len(vec.V) > 0 {
+		vec.P = tk.Between(
+			vec.V[0].Pos().BumpLeft(),
+			vec.V[len(vec.V)-1].Pos().BumpRight(),
+		)
+	}
 	return vec, nil
 }
 
@@ -327,34 +644,41 @@ func (p *Parser) parseDotList(list *ex.List) (ex.Expr, *e.Error) {
 
 func (p *Parser) parseMap() (ex.Expr, *e.Error) {
 	mp := &ex.Map{}
+	kvs := []ex.Expr{}
 	for p.inRange() && !p.is(tk.RightBrace{}) {
-		k, err := p.parse()
+		expr, err := p.parse()
 		if err != nil {
 			return nil, err
 		}
-		v, err := p.parse()
-		if err != nil {
-			return nil, err
+		if expr == nil {
+			continue
 		}
-		mp.AddKV(k, v)
+		kvs = append(kvs, expr)
 	}
 	if err := p.eat(tk.RightBrace{}); err != nil {
 		return nil, err
 	}
+	if len(kvs)%2 != 0 {
+		return nil, p.errWas(kvs[len(kvs)-1], "expected value for key", kvs[len(kvs)-1])
+	}
+	for i := 0; i < len(kvs); i += 2 {
+		mp.AddKV(kvs[i], kvs[i+1])
+	}
 	return mp, nil
 }
 
 func (p *Parser) parseVariableArg(pos tk.Position) (ex.Expr, *e.Error) {
-	arg, err := p.parse()
+	arg, err := p.parseNext()
 	if err != nil {
 		return nil, err
 	}
-	ident, ok := arg.(ex.Identifier)
-	if !ok {
+	switch arg.(type) {
+	case ex.Identifier, *ex.Map:
+	default:
 		return nil, p.errLastTokenType("expected identifier", arg)
 	}
 	return &ex.VariableArg{
-		V: ident,
+		V: arg,
 		P: tk.Between(pos, arg.Pos()),
 	}, nil
 }
@@ -367,7 +691,7 @@ func (p *Parser) parseDot(dot tk.Dot) (ex.Identifier, *e.Error) {
 }
 
 func (p *Parser) parseQuote(q tk.Quote) (ex.Expr, *e.Error) {
-	expr, err := p.parse()
+	expr, err := p.parseNext()
 	if err != nil {
 		return nil, err
 	}
@@ -378,7 +702,7 @@ func (p *Parser) parseQuote(q tk.Quote) (ex.Expr, *e.Error) {
 }
 
 func (p *Parser) parseQuasiquote(q tk.Quasiquote) (ex.Expr, *e.Error) {
-	expr, err := p.parse()
+	expr, err := p.parseNext()
 	if err != nil {
 		return nil, err
 	}
@@ -401,6 +725,11 @@ func (p *Parser) parseUnquote(c tk.Comma) (ex.Expr, *e.Error) {
 		if err != nil {
 			return nil, err
 		}
+		if expr == nil {
+			if expr, err = p.parseNext(); err != nil {
+				return nil, err
+			}
+		}
 		return &ex.Unquote{
 			E: expr,
 			P: tk.Between(c.Pos(), expr.Pos()),
@@ -409,7 +738,7 @@ func (p *Parser) parseUnquote(c tk.Comma) (ex.Expr, *e.Error) {
 }
 
 func (p *Parser) parseUnquoteSplicing(c tk.AtSign) (ex.Expr, *e.Error) {
-	expr, err := p.parse()
+	expr, err := p.parseNext()
 	if err != nil {
 		return nil, err
 	}
@@ -419,6 +748,78 @@ func (p *Parser) parseUnquoteSplicing(c tk.AtSign) (ex.Expr, *e.Error) {
 	}, nil
 }
 
+// Parses and throws away the next form.
+//
+//	(+ 1 #_ (expensive) 2) => (+ 1 2)
+func (p *Parser) parseDiscard() (ex.Expr, *e.Error) {
+	if _, err := p.parseNext(); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// Parses the anonymous function shorthand, where
+// % is the first argument, %n the nth and %& the rest.
+// % at the head of a list is still the remainder
+// operator, so calling the first argument is (%1).
+//
+//	#(+ %1 %2)  => (fn [__p1 __p2] (+ __p1 __p2))
+//	#(% % 2)    => (fn [__p1] (% __p1 2))
+func (p *Parser) parseFnShorthand(f tk.FnShorthand) (ex.Expr, *e.Error) {
+	arity, rest := 0, false
+	args := map[int]tk.Token{}
+	depth := 1
+	for i := p.i; i < len(p.tokens) && depth > 0; i++ {
+		switch t := p.tokens[i].(type) {
+		case tk.FnShorthand:
+			return nil, e.FromToken(t, fmt.Sprintf("%s: %s",
+				h.Bold(t.Pos().String()), h.Red("nested #() is not allowed")))
+		case tk.LeftParen:
+			depth++
+		case tk.RightParen:
+			depth--
+		case tk.Operator:
+			_, head := p.tokens[i-1].(tk.LeftParen)
+			if t.V == "%" && !head && i > p.i {
+				args[i] = tk.Identifier{V: "__p1", P: t.P}
+				arity = max(arity, 1)
+			}
+		case tk.Identifier:
+			if t.V == "%&" {
+				args[i] = tk.Identifier{V: "__rest", P: t.P}
+				rest = true
+			} else if n, err := strconv.Atoi(strings.TrimPrefix(t.V, "%")); err == nil && strings.HasPrefix(t.V, "%") {
+				args[i] = tk.Identifier{V: fmt.Sprintf("__p%d", n), P: t.P}
+				arity = max(arity, n)
+			}
+		}
+	}
+	// The tokens are the lexer's, so they are
+	// replaced in a copy.
+	if len(args) > 0 {
+		p.tokens = slices.Clone(p.tokens)
+		for i, t := range args {
+			p.tokens[i] = t
+		}
+	}
+	body, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	params := &ex.Vec{P: f.P}
+	for i := 1; i <= arity; i++ {
+		params.Append(ex.Identifier{V: fmt.Sprintf("__p%d", i), P: f.P})
+	}
+	if rest {
+		params.Append(&ex.VariableArg{V: ex.Identifier{V: "__rest", P: f.P}, P: f.P})
+	}
+	return &ex.AnonymousFn{
+		Params: params,
+		Body:   body,
+		P:      tk.Between(f.P, body.Pos().BumpRight()),
+	}, nil
+}
+
 func (p *Parser) parseMacro(list *ex.List) (ex.Expr, *e.Error) {
 	m := list.Pop()
 	if m == nil {
@@ -458,31 +859,9 @@ func (p *Parser) parseMatch(list *ex.List) (ex.Expr, *e.Error) {
 		body := list.Pop()
 		switch cmp := cmpe.(type) {
 		case *ex.List:
-			cleancmp := strings.ReplaceAll(cmp.String(), "_", "0")
-			s.WriteString(fmt.Sprintf("(if (and (= (length %s) (length %s))", cond, cleancmp))
-			if len(cmp.V) > 0 {
-				for i, expr := range cmp.V {
-					if expr.String() == "_" {
-						// Ignore.
-					} else {
-						s.WriteString(fmt.Sprintf(" (= %s (get %s %d))", expr, cond, i))
-					}
-				}
-			}
-			s.WriteString(fmt.Sprintf(") %s ", body))
+			s.WriteString(fmt.Sprintf("(if %s %s ", matchPattern(cond, cmp.V), body))
 		case *ex.Vec:
-			cleancmp := strings.ReplaceAll(cmp.String(), "_", "0")
-			s.WriteString(fmt.Sprintf("(if (and (= (length %s) (length %s))", cond, cleancmp))
-			if len(cmp.V) > 0 {
-				for i, expr := range cmp.V {
-					if expr.String() == "_" {
-						// Ignore.
-					} else {
-						s.WriteString(fmt.Sprintf(" (= %s (get %s %d))", expr, cond, i))
-					}
-				}
-			}
-			s.WriteString(fmt.Sprintf(") %s ", body))
+			s.WriteString(fmt.Sprintf("(if %s %s ", matchPattern(cond, cmp.V), body))
 		case ex.Atom:
 			if cmp.V != "else" {
 				return nil, p.errWas(cmp, "only :else atoms are supported as of now", cmp)
@@ -506,61 +885,3 @@ func (p *Parser) parseMatch(list *ex.List) (ex.Expr, *e.Error) {
 	}
 	return nliste[0], nil
 }
-
-func (p *Parser) parseThreadFirst(list *ex.List) (ex.Expr, *e.Error) {
-	if len(list.V) == 0 {
-		return nil, p.errWas(list, "expected thread first", list)
-	}
-	_ = list.Pop()
-	fst := list.Pop()
-	snde := list.Pop()
-	snd, ok := snde.(*ex.List)
-	if !ok {
-		return nil, p.errWas(snde, "expected list", snde)
-	}
-	if len(snd.V) > 1 {
-		snd.V = slices.Insert(snd.V, 1, fst)
-	} else {
-		snd.Append(fst)
-	}
-	last := snd
-	for len(list.V) > 0 {
-		nexte := list.Pop()
-		next, ok := nexte.(*ex.List)
-		if !ok {
-			return nil, p.errWas(nexte, "expected list", nexte)
-		}
-		if len(next.V) > 1 {
-			next.V = slices.Insert(next.V, 1, ex.Expr(last))
-		} else {
-			next.Append(last)
-		}
-		last = next
-	}
-	return last, nil
-}
-
-func (p *Parser) parseThreadLast(list *ex.List) (ex.Expr, *e.Error) {
-	if len(list.V) == 0 {
-		return nil, p.errWas(list, "expected thread last", list)
-	}
-	_ = list.Pop()
-	fst := list.Pop()
-	snde := list.Pop()
-	snd, ok := snde.(*ex.List)
-	if !ok {
-		return nil, p.errWas(snde, "expected list", snde)
-	}
-	snd.Append(fst)
-	last := snd
-	for len(list.V) > 0 {
-		nexte := list.Pop()
-		next, ok := nexte.(*ex.List)
-		if !ok {
-			return nil, p.errWas(nexte, "expected list", nexte)
-		}
-		next.Append(last)
-		last = next
-	}
-	return last, nil
-}
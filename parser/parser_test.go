@@ -111,10 +111,54 @@ func TestParse(t *testing.T) {
 			input:  "(fn id-array [& x] \"Id function for arrays only.\" x)",
 			output: "(fn id-array [& x] \"Id function for arrays only.\" x)",
 		},
+		{
+			input:  "(async-fn get [url] (await (fetch url)))",
+			output: "(async-fn get [url] (await (fetch url)))",
+		},
+		{
+			input:  "(async (fn [x] (await x)))",
+			output: "(async-fn [x] (await x))",
+		},
+		{
+			input:  "(gen-fn naturals [] (yield 1))",
+			output: "(gen-fn naturals [] (yield 1))",
+		},
+		{
+			input:  "(loop [i 0 acc []] (if (< i 3) (recur (+ i 1) acc) acc))",
+			output: "(loop [i 0 acc []] (if (< i 3) (recur (+ i 1) acc) acc))",
+		},
+		{
+			input:  "(fn area \"Area of a circle or rectangle.\" ([r] (* r r)) ([w h] (* w h)))",
+			output: "(fn area \"Area of a circle or rectangle.\" ([r] (* r r)) ([w h] (* w h)))",
+		},
+		{
+			input:  "(fn ([] 0) ([x & xs] x))",
+			output: "(fn ([] 0) ([x & xs] x))",
+		},
+		{
+			input:  "(fn run [cmd (n 1) & {:keys [verbose]}] cmd)",
+			output: "(fn run [cmd (n 1) & {:keys [verbose]}] cmd)",
+		},
 		{
 			input:  "(. (Array 10) (fill 1) (map (fn [_ i] i)))",
 			output: "(. (Array 10) (fill 1) (map (fn [_ i] i)))",
 		},
+		{
+			input:  "(if (< 1 2) 1)",
+			output: "(if (< 1 2) 1)",
+		},
+		{
+			input:  "(when x (println x) x)",
+			output: "(when x (println x) x)",
+		},
+		{
+			input:  "(cond a 1 :else 2)",
+			output: "(cond a 1 :else 2)",
+		},
+		{
+			input:  "(case x 1 \"one\" (:a :b) \"atom\" \"other\")",
+			output: "(case x 1 \"one\" (:a :b) \"atom\" \"other\")",
+		},
 		{
 			input:  "(if (< 1 2) 1 2)",
 			output: "(if (< 1 2) 1 2)",
@@ -157,19 +201,84 @@ func TestParse(t *testing.T) {
 		},
 		{
 			input:  "(match [1 2] [_ 2] \"_ two\" :else \"unknown\")",
-			output: "(if (and (= (length [1 2]) (length [0 2])) (= 2 (get [1 2] 1))) \"_ two\" \"unknown\")",
+			output: "(if (and (= (length [1 2]) 2) (= 2 (get [1 2] 1))) \"_ two\" \"unknown\")",
+		},
+		{
+			input:  "(match x [1 [2 3]] \"nested\" [1 _] \"one\" :else \"unknown\")",
+			output: "(if (= x [1 [2 3]]) \"nested\" (if (and (= (length x) 2) (= 1 (get x 0))) \"one\" \"unknown\"))",
 		},
 		{
 			input:  "(match (1 2) (_ 2) \"_ two\" :else \"unknown\")",
-			output: "(if (and (= (length (1 2)) (length (0 2))) (= 2 (get (1 2) 1))) \"_ two\" \"unknown\")",
+			output: "(if (and (= (length (1 2)) 2) (= 2 (get (1 2) 1))) \"_ two\" \"unknown\")",
+		},
+		{
+			input:  "(try (risky) (catch e (println e)) (finally (cleanup)))",
+			output: "(try (risky) (catch e (println e)) (finally (cleanup)))",
 		},
 		{
+			input:  "(try (risky) (finally (cleanup)))",
+			output: "(try (risky) (finally (cleanup)))",
+		},
+		{
+			input:  "(throw (ex-info \"oops\" {:a 1}))",
+			output: "(throw (ex-info \"oops\" {:a 1}))",
+		},
+		{
+			input:  "(+ 1 #_ 2 3)",
+			output: "(+ 1 3)",
+		},
+		{
+			input:  "[1 #_ #_ 2 3 4]",
+			output: "[1 4]",
+		},
+		{
+			input:  "{:a 1 #_ :b #_ 2}",
+			output: "{:a 1}",
+		},
+		{
+			input:  "#_ (println \"ignored\") '#_ x y",
+			output: "'y",
+		},
+		{
+			input:  "(do #| (println \"ignored\") |# 1)",
+			output: "(do 1)",
+		},
+		{
+			// Threading steps are expanded later.
 			input:  "(-> [1 2 3] (get 2) (println))",
-			output: "(println (get [1 2 3] 2))",
+			output: "(-> [1 2 3] (get 2) (println))",
+		},
+		{
+			input:  "(cond-> x true (get 2) false (set 1))",
+			output: "(cond-> x true (get 2) false (set 1))",
+		},
+		{
+			input:  "#(+ % 1)",
+			output: "(fn [__p1] (+ __p1 1))",
+		},
+		{
+			input:  "#(str %2 %&)",
+			output: "(fn [__p1 __p2 & __rest] (str __p2 __rest))",
+		},
+		{
+			input:  "#(if (%1 :a) [%] {})",
+			output: "(fn [__p1] (if (__p1 :a) [__p1] {}))",
+		},
+		{
+			input:  "#(% %1 2)",
+			output: "(fn [__p1] (% __p1 2))",
+		},
+		{
+			input:  "#((% % 2) %)",
+			output: "(fn [__p1] ((% __p1 2) __p1))",
+		},
+		{
+			input:  "(map #(* % %) xs)",
+			output: "(map (fn [__p1] (* __p1 __p1)) xs)",
 		},
 		{
-			input:  "(->> [1 2 3] (map (fn [x] (+ x 1))) (println))",
-			output: "(println (map (fn [x] (+ x 1)) [1 2 3]))",
+			input:  "#(rand)",
+			output: "(fn [] (rand))",
 		},
 	}
 	for _, tt := range tests {
@@ -256,7 +365,7 @@ func TestError(t *testing.T) {
 			output: &e.Error{
 				Start: 0,
 				End:   4,
-				Msg:   "if requires three expressions",
+				Msg:   "if requires two or three expressions",
 			},
 		},
 		{
@@ -307,6 +416,222 @@ func TestError(t *testing.T) {
 				Msg:   "expected arguments for dot list",
 			},
 		},
+		{
+			input: "(try 1)",
+			output: &e.Error{
+				Start: 0,
+				End:   7,
+				Msg:   "try requires a body and catch or finally",
+			},
+		},
+		{
+			input: "(try 1 (println 2))",
+			output: &e.Error{
+				Start: 7,
+				End:   18,
+				Msg:   "expected catch or finally",
+			},
+		},
+		{
+			input: "(try 1 (finally 2) (catch e 3))",
+			output: &e.Error{
+				Start: 7,
+				End:   18,
+				Msg:   "finally must come last",
+			},
+		},
+		{
+			input: "(try 1 (catch 2 3))",
+			output: &e.Error{
+				Start: 14,
+				End:   15,
+				Msg:   "expected identifier",
+			},
+		},
+		{
+			input: "(try 1 (catch e))",
+			output: &e.Error{
+				Start: 7,
+				End:   16,
+				Msg:   "catch requires a binding and one expression",
+			},
+		},
+		{
+			input: "(async 1)",
+			output: &e.Error{
+				Start: 7,
+				End:   8,
+				Msg:   "expected function",
+			},
+		},
+		{
+			input: "(await)",
+			output: &e.Error{
+				Start: 0,
+				End:   7,
+				Msg:   "await requires one expression",
+			},
+		},
+		{
+			input: "(yield)",
+			output: &e.Error{
+				Start: 0,
+				End:   7,
+				Msg:   "yield requires one expression",
+			},
+		},
+		{
+			input: "(async (gen-fn [] (yield 1)))",
+			output: &e.Error{
+				Start: 0,
+				End:   29,
+				Msg:   "async generators are not supported",
+			},
+		},
+		{
+			input: "(loop [i 0])",
+			output: &e.Error{
+				Start: 0,
+				End:   12,
+				Msg:   "loop requires bindings and one expression",
+			},
+		},
+		{
+			input: "(loop i i)",
+			output: &e.Error{
+				Start: 6,
+				End:   7,
+				Msg:   "expected bindings",
+			},
+		},
+		{
+			input: "(loop [i] i)",
+			output: &e.Error{
+				Start: 6,
+				End:   9,
+				Msg:   "expected value for binding",
+			},
+		},
+		{
+			input: "(fn f ([x] x) ([y] y))",
+			output: &e.Error{
+				Start: 14,
+				End:   21,
+				Msg:   "duplicate arity",
+			},
+		},
+		{
+			input: "(fn f ([x] x) 1)",
+			output: &e.Error{
+				Start: 14,
+				End:   15,
+				Msg:   "expected arity",
+			},
+		},
+		{
+			input: "(fn f ([x]))",
+			output: &e.Error{
+				Start: 6,
+				End:   11,
+				Msg:   "arity requires parameters and one expression",
+			},
+		},
+		{
+			input: "(when x)",
+			output: &e.Error{
+				Start: 0,
+				End:   8,
+				Msg:   "when requires a condition and a body",
+			},
+		},
+		{
+			input: "(cond a 1 b)",
+			output: &e.Error{
+				Start: 0,
+				End:   12,
+				Msg:   "cond requires pairs of tests and expressions",
+			},
+		},
+		{
+			input: "(case x y 1)",
+			output: &e.Error{
+				Start: 8,
+				End:   9,
+				Msg:   "case values must be literals or atoms",
+			},
+		},
+		{
+			input: "(case x 1 :a (2 1) :b)",
+			output: &e.Error{
+				Start: 16,
+				End:   17,
+				Msg:   "duplicate case value",
+			},
+		},
+		{
+			input: "(throw)",
+			output: &e.Error{
+				Start: 0,
+				End:   7,
+				Msg:   "throw requires one expression",
+			},
+		},
+		{
+			input: "(+ 1 #_)",
+			output: &e.Error{
+				Start: 7,
+				End:   8,
+				Msg:   "unexpected token",
+			},
+		},
+		{
+			input: "{:a #_ 1}",
+			output: &e.Error{
+				Start: 1,
+				End:   3,
+				Msg:   "expected value for key",
+			},
+		},
+		{
+			input: "#(map #(+ % 1) %)",
+			output: &e.Error{
+				Start: 6,
+				End:   8,
+				Msg:   "nested #() is not allowed",
+			},
+		},
+		{
+			input: "(new)",
+			output: &e.Error{
+				Start: 0,
+				End:   5,
+				Msg:   "new requires a class",
+			},
+		},
+		{
+			input: "(js*)",
+			output: &e.Error{
+				Start: 0,
+				End:   5,
+				Msg:   "js* requires a template",
+			},
+		},
+		{
+			input: "(js* x)",
+			output: &e.Error{
+				Start: 5,
+				End:   6,
+				Msg:   "expected template string",
+			},
+		},
+		{
+			input: "(set! x)",
+			output: &e.Error{
+				Start: 0,
+				End:   8,
+				Msg:   "set! requires two expressions",
+			},
+		},
 		{
 			input: "(macro)",
 			output: &e.Error{
@@ -3,6 +3,7 @@ package pp
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -20,17 +21,50 @@ func FromJS(js []byte) (string, error) {
 func Object(obj any) (string, error) {
 	var s strings.Builder
 	switch obj := obj.(type) {
+	case nil:
+		return "nil", nil
+	case string:
+		return fmt.Sprintf("%q", obj), nil
 	case map[string]any:
+		if atom, ok := tagged(obj, "$atom"); ok {
+			return fmt.Sprintf(":%s", atom), nil
+		}
+		if symbol, ok := tagged(obj, "$symbol"); ok {
+			return symbol, nil
+		}
+		if xs, ok := obj["$set"].([]any); ok && len(obj) == 1 {
+			vs, err := Object(xs)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("#{%s}", vs[1:len(vs)-1]), nil
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
 		s.WriteByte('{')
-		for k, v := range obj {
-			s.WriteString(fmt.Sprintf("%s %v", k, v))
+		for i, k := range keys {
+			v, err := Object(obj[k])
+			if err != nil {
+				return "", err
+			}
+			s.WriteString(fmt.Sprintf("%s %s", key(k), v))
+			if i < len(keys)-1 {
+				s.WriteByte(' ')
+			}
 		}
 		s.WriteByte('}')
 		return s.String(), nil
 	case []any:
 		s.WriteByte('[')
 		for i, v := range obj {
-			s.WriteString(fmt.Sprintf("%v", v))
+			vstr, err := Object(v)
+			if err != nil {
+				return "", err
+			}
+			s.WriteString(vstr)
 			if i < len(obj)-1 {
 				s.WriteByte(' ')
 			}
@@ -41,3 +75,21 @@ func Object(obj any) (string, error) {
 		return fmt.Sprintf("%v", obj), nil
 	}
 }
+
+// Values that JSON can't represent are tagged
+// by the runtime as {"$tag": value}.
+func tagged(obj map[string]any, tag string) (string, bool) {
+	if len(obj) != 1 {
+		return "", false
+	}
+	v, ok := obj[tag].(string)
+	return v, ok
+}
+
+// Atoms become ":name" when used as object keys.
+func key(k string) string {
+	if strings.HasPrefix(k, ":") && len(k) > 1 {
+		return k
+	}
+	return fmt.Sprintf("%q", k)
+}
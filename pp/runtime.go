@@ -3,9 +3,11 @@ package pp
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	h "github.com/fholmqvist/remlisp/highlight"
+	"github.com/fholmqvist/remlisp/transpiler/mangle"
 )
 
 func ParseResponse(input []byte, out string) string {
@@ -22,6 +24,9 @@ func ParseResponseRaw(input []byte, out string) (string, error) {
 		return "", err
 	}
 	if r, ok := result["result"]; ok {
+		if r == nil {
+			return "nil", nil
+		}
 		rstr, ok := r.(string)
 		if !ok {
 			return fmt.Sprintf("%s", r), nil
@@ -31,6 +36,14 @@ func ParseResponseRaw(input []byte, out string) (string, error) {
 				name := bytes.Split(input, []byte("(fn "))[1]
 				name = name[:bytes.Index(name, []byte(" "))]
 				return h.Code(fmt.Sprintf("<fn %s>", name)), nil
+			} else if bytes.Contains(input, []byte("(async-fn ")) {
+				name := bytes.Split(input, []byte("(async-fn "))[1]
+				name = name[:bytes.Index(name, []byte(" "))]
+				return h.Code(fmt.Sprintf("<async-fn %s>", name)), nil
+			} else if bytes.Contains(input, []byte("(gen-fn ")) {
+				name := bytes.Split(input, []byte("(gen-fn "))[1]
+				name = name[:bytes.Index(name, []byte(" "))]
+				return h.Code(fmt.Sprintf("<gen-fn %s>", name)), nil
 			} else if bytes.Contains(input, []byte("(macro ")) {
 				name := bytes.Split(input, []byte("(macro "))[1]
 				name = name[:bytes.Index(name, []byte(" "))]
@@ -47,6 +60,6 @@ func ParseResponseRaw(input []byte, out string) (string, error) {
 		if !ok {
 			return "nil", nil
 		}
-		return "", fmt.Errorf(errstr.(string))
+		return "", errors.New(mangle.Text(errstr.(string)))
 	}
 }
@@ -26,6 +26,7 @@ const (
 	OPEN_BRACKET   = `[`
 	OPEN_BRACE     = `{`
 	QUOTATION      = `\"`
+	PIPE           = `|`
 )
 
 func (r *Repl) input() []byte {
@@ -78,6 +79,8 @@ OUTER:
 			r.line.openBrace()
 		case QUOTATION:
 			r.line.quotation()
+		case PIPE:
+			r.line.pipe()
 		case `\n`, `\r`:
 			break OUTER
 		default:
@@ -65,6 +65,17 @@ func (l *line) quotation() {
 	l.left()
 }
 
+// Closes block comments, #| becomes #| |#.
+func (l *line) pipe() {
+	if l.cursor > 0 && l.line[l.cursor-1] == '#' {
+		l.add([]byte("||#"))
+		l.left()
+		l.left()
+	} else {
+		l.add([]byte("|"))
+	}
+}
+
 func (l *line) left() {
 	if l.cursor > 0 {
 		l.cursor--
@@ -119,6 +130,8 @@ func (l *line) backspace() {
 				l.line = slices.Delete(l.line, l.cursor-1, l.cursor+1)
 			} else if curr == '"' && next == '"' {
 				l.line = slices.Delete(l.line, l.cursor-1, l.cursor+1)
+			} else if l.inEmptyBlockComment() {
+				l.line = slices.Delete(l.line, l.cursor-1, l.cursor+2)
 			} else {
 				l.line = slices.Delete(l.line, l.cursor-1, l.cursor)
 			}
@@ -129,6 +142,12 @@ func (l *line) backspace() {
 	}
 }
 
+func (l *line) inEmptyBlockComment() bool {
+	return l.cursor > 1 && l.cursor+1 < len(l.line) &&
+		string(l.line[l.cursor-2:l.cursor]) == "#|" &&
+		string(l.line[l.cursor:l.cursor+2]) == "|#"
+}
+
 func (l *line) delete() {
 	if l.cursor == len(l.line) {
 		return
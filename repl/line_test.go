@@ -93,6 +93,28 @@ func TestLineCommands(t *testing.T) {
 			},
 			expected: "\"\"",
 		},
+		{
+			input: "#",
+			action: func(l *line) {
+				l.pipe()
+			},
+			expected: "#||#",
+		},
+		{
+			input: "",
+			action: func(l *line) {
+				l.pipe()
+			},
+			expected: "|",
+		},
+		{
+			input: "#",
+			action: func(l *line) {
+				l.pipe()
+				l.backspace()
+			},
+			expected: "#",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.input, func(t *testing.T) {
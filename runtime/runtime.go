@@ -10,6 +10,8 @@ import (
 	_ "embed"
 
 	e "github.com/fholmqvist/remlisp/err"
+	"github.com/fholmqvist/remlisp/stdlib"
+	"github.com/fholmqvist/remlisp/transpiler/mangle"
 )
 
 //go:embed runtime.mjs
@@ -46,12 +48,22 @@ func New() (*Runtime, *e.Error) {
 	go func() {
 		scanner := bufio.NewScanner(stderr)
 		for scanner.Scan() {
-			log.Printf("stderr: %s", scanner.Text())
+			log.Printf("stderr: %s", mangle.Text(scanner.Text()))
 		}
 	}()
+	if _, err := r.SendByte(stdlib.StdJS); err != nil {
+		return nil, err
+	}
 	return r, nil
 }
 
+// Stops the Deno process.
+func (r *Runtime) Close() {
+	r.stdin.Close()
+	r.deno.Process.Kill()
+	r.deno.Wait()
+}
+
 func (r *Runtime) Send(js string) (string, *e.Error) {
 	return r.SendByte([]byte(js))
 }
@@ -3,33 +3,56 @@ import process from 'node:process'
 
 const context = createContext({ process: process })
 
-process.stdin.on('data', (data) => {
+const ATOM = Symbol.for('remlisp.atom')
+const SYMBOL = Symbol.for('remlisp.symbol')
+
+process.stdin.on('data', async (data) => {
   try {
-    const input = data?.toString().trim()
+    let input = data?.toString().trim()
     if (input) {
       if (input.startsWith('{')) {
         input = `(${input})`
       } else if (input == 'env') {
-        sendResult(JSON.stringify(Object.keys(context)) + '\n')
+        sendResult(Object.keys(context))
         return
       }
 
-      const result = runInContext(`'use strict'; ${input}`, context)
-      sendResult(result == null ? 'nil' : result)
+      // Code that awaits comes in an async function,
+      // since scripts can't await at the top level.
+      const result = await runInContext(`'use strict'; ${input}`, context)
+      sendResult(result)
     }
   } catch (error) {
     sendError(error, data)
   }
 })
 
+// Tags values that JSON can't represent,
+// so that they can be printed as remlisp.
+// Undefined is nil, like null, instead of
+// being dropped from objects.
+function replacer(_, value) {
+  if (value === undefined) {
+    return null
+  }
+  if (typeof value === 'function' && value[ATOM] !== undefined) {
+    return { $atom: value[ATOM] }
+  }
+  if (value != null && typeof value === 'object' && value[SYMBOL] !== undefined) {
+    return { $symbol: value[SYMBOL] }
+  }
+  return value
+}
+
 function sendResult(result) {
   process.stdout.write(
-    JSON.stringify({ result: JSON.stringify(result) }) + '\n'
+    JSON.stringify({ result: JSON.stringify(result ?? null, replacer) }) +
+      '\n',
   )
 }
 
 function sendError(error, input) {
   process.stdout.write(
-    JSON.stringify({ error: error.message, input: input }) + '\n'
+    JSON.stringify({ error: error.message, input: input }) + '\n',
   )
 }
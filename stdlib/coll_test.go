@@ -0,0 +1,111 @@
+package stdlib_test
+
+import (
+	"testing"
+)
+
+func TestCollections(t *testing.T) {
+	tests := []struct{ input, output string }{
+		{
+			input:  "_vector(1, 2, 3)",
+			output: "[1 2 3]",
+		},
+		{
+			input:  "_conj(_vector(1), 2, 3)",
+			output: "[1 2 3]",
+		},
+		{
+			input:  "_assoc(_vector(1, 2), 0, 3)",
+			output: "[3 2]",
+		},
+		{
+			input:  "Array.from({ length: 2000 }).reduce((v, _, i) => v.conj(i), _vector()).get(1999)",
+			output: "1999",
+		},
+		{
+			input:  "Array.from({ length: 2000 }).reduce((v, _, i) => v.assoc(i, -i), _vector(...Array(2000).keys())).get(1500)",
+			output: "-1500",
+		},
+		{
+			input:  "_hashMap(_atom('a'), 1, _atom('b'), 2)",
+			output: "{:a 1 :b 2}",
+		},
+		{
+			input:  "_get(_hashMap([1, 2], 'vector key'), _vector(1, 2))",
+			output: "\"vector key\"",
+		},
+		{
+			input:  "_dissoc(_hashMap(_atom('a'), 1, _atom('b'), 2), _atom('a'))",
+			output: "{:b 2}",
+		},
+		{
+			input:  "Array.from({ length: 1000 }).reduce((m, _, i) => m.assoc(i, i), _hashMap()).size",
+			output: "1000",
+		},
+		{
+			input:  "(({ [_atom('a')]: a, b }) => [a, b])(_hashMap(_atom('a'), 1, 'b', 2))",
+			output: "[1 2]",
+		},
+		{
+			input:  "_hashSet(1, 2, 1, _vector(3), [3])",
+			output: "#{1 2 [3]}",
+		},
+		{
+			input:  "_atom('a')(_hashMap(_atom('a'), 1))",
+			output: "1",
+		},
+		{
+			input:  "_equals(_hashMap(_atom('a'), [1]), _hashMap(_atom('a'), _vector(1)))",
+			output: "true",
+		},
+		{
+			input:  "_hash(_vector(1, 2)) === _hash([1, 2])",
+			output: "true",
+		},
+		{
+			input:  "[_equals(_hashMap(_atom('a'), 1), { [_atom('a')]: 1 }), _equals({ [_atom('a')]: 1 }, _hashMap(_atom('a'), 1))]",
+			output: "[true true]",
+		},
+		{
+			input:  "_hash(_hashMap(_atom('a'), 1, 'b', [2])) === _hash({ [_atom('a')]: 1, b: _vector(2) })",
+			output: "true",
+		},
+		{
+			input:  "[_equals(_hashMap(1, 1), { 1: 1 }), _equals(_hashMap(_atom('a'), 1, ':a', 1), { ':a': 1, b: 1 })]",
+			output: "[false false]",
+		},
+		{
+			input:  "[_equals(NaN, NaN), _equals([NaN], [NaN]), _equals(_vector(NaN), _vector(NaN))]",
+			output: "[false false false]",
+		},
+		{
+			input:  "_update({ n: 1 }, 'n', (n) => n + 1)",
+			output: "{\"n\" 2}",
+		},
+		{
+			input:  "_getIn(_fromJS({ a: [1, 2] }, true), [_atom('a'), 1])",
+			output: "2",
+		},
+		{
+			input:  "_getIn({}, [_atom('a'), _atom('b')], 0)",
+			output: "0",
+		},
+		{
+			input:  "_assocIn(_hashMap(), [_atom('a'), _atom('b')], 1)",
+			output: "{:a {:b 1}}",
+		},
+		{
+			input:  "_toJS(_hashMap(_atom('a'), _vector(1, _atom('b'))))",
+			output: "{\"a\" [1 \"b\"]}",
+		},
+		{
+			input:  "(() => { const xs = [1, 2, 3]; _reverse(xs); _shuffle(xs); return xs })()",
+			output: "[1 2 3]",
+		},
+		{
+			input:  "(() => { const m = { a: 1 }; _assoc(m, 'b', 2); _dissoc(m, 'a'); return m })()",
+			output: "{\"a\" 1}",
+		},
+	}
+	expectJS(t, tests)
+}
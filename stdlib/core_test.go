@@ -0,0 +1,23 @@
+package stdlib_test
+
+import (
+	"testing"
+)
+
+func TestCore(t *testing.T) {
+	tests := []struct{ input, output string }{
+		{
+			input:  "[_atom('b'), _symbol('a')].map(String)",
+			output: "[\":b\" \"a\"]",
+		},
+		{
+			input:  "[_atom('b'), _symbol('a')].map((x) => x[Symbol.for('nodejs.util.inspect.custom')]())",
+			output: "[\":b\" \"a\"]",
+		},
+		{
+			input:  "[_atom('b'), _symbol('a')].map((x) => x[Symbol.for('Deno.customInspect')]())",
+			output: "[\":b\" \"a\"]",
+		},
+	}
+	expectJS(t, tests)
+}
@@ -0,0 +1,131 @@
+package stdlib_test
+
+import (
+	"testing"
+)
+
+func TestFunctions(t *testing.T) {
+	tests := []struct{ input, output string }{
+		{
+			input:  "(identity :a)",
+			output: ":a",
+		},
+		{
+			input:  "(filter string? [1 \"a\" :b])",
+			output: "[\"a\"]",
+		},
+		{
+			input:  "((fn [] (do (var eval 3) (var arguments 2) (+ eval arguments))))",
+			output: "5",
+		},
+		{
+			input:  "((constantly 7) 1 2)",
+			output: "7",
+		},
+		{
+			input:  "(map (complement even?) [1 2])",
+			output: "[true false]",
+		},
+		{
+			input:  "((comp - +) 1 2)",
+			output: "-3",
+		},
+		{
+			input:  "((comp #(* % 2) #(+ % 1)) 1)",
+			output: "4",
+		},
+		{
+			input:  "((comp) :a)",
+			output: ":a",
+		},
+		{
+			input:  "((partial + 1 2) 3 4)",
+			output: "10",
+		},
+		{
+			input:  "((juxt first last length) [1 2 3])",
+			output: "[1 3 3]",
+		},
+		{
+			input:  "((juxt :a :b) {:a 1 :b 2})",
+			output: "[1 2]",
+		},
+		{
+			input:  "(do (var n 0) (var f (memoize (fn [x] (do (set n (+ n 1)) x)))) [(f [1]) (f [1]) (f 2) n])",
+			output: "[[1] [1] 2 2]",
+		},
+		{
+			input:  "(apply + 1 2 [3 4])",
+			output: "10",
+		},
+		{
+			input:  "(apply + (vector 1 2))",
+			output: "3",
+		},
+		{
+			input:  "(apply Math.max 1 nil)",
+			output: "1",
+		},
+		{
+			input:  "((some-fn even? #(> % 10)) 1 3 11)",
+			output: "true",
+		},
+		{
+			input:  "((some-fn :a :b) {:b 0})",
+			output: "0",
+		},
+		{
+			input:  "((some-fn even?) 1 3)",
+			output: "false",
+		},
+		{
+			input:  "((every-pred odd? #(> % 0)) 1 3)",
+			output: "true",
+		},
+		{
+			input:  "((every-pred odd? #(> % 2)) 1 3)",
+			output: "false",
+		},
+		{
+			input:  "(map #(* % %) [1 2 3])",
+			output: "[1 4 9]",
+		},
+		{
+			input:  "(#(+ %2 (length %&)) 1 2 3 4)",
+			output: "4",
+		},
+		{
+			input:  "(reduce #(+ %1 %2) 10 [1 2 3])",
+			output: "16",
+		},
+		{
+			input:  "(map #(% % 3) [7 8])",
+			output: "[1 2]",
+		},
+		{
+			input:  "((fn [xs] (do (var n 0) (each [x xs] (set n (+ n x))) (each [x xs] (set n (* n x))) n)) [1 2])",
+			output: "6",
+		},
+		{
+			input:  "(loop [i 0 fs []] (if (< i 3) (recur (+ i 1) (conj fs (fn [] i))) (map (fn [f] (f)) fs)))",
+			output: "[0 1 2]",
+		},
+		{
+			input:  "((fn [i fs] (if (< i 3) (recur (+ i 1) (conj fs (fn [] i))) (map (fn [f] (f)) fs))) 0 [])",
+			output: "[0 1 2]",
+		},
+		{
+			input:  "(loop [a 1 b (+ a 1)] (if (< a 3) (recur (+ a 1) (+ b a)) [a b]))",
+			output: "[3 5]",
+		},
+		{
+			input:  "((fn [[a b] n] (if (> n 0) (recur [b a] (- n 1)) [a b])) [1 2] 3)",
+			output: "[2 1]",
+		},
+		{
+			input:  "((fn [n] (as-> (+ n 1) n (* n 2))) 3)",
+			output: "8",
+		},
+	}
+	expectRem(t, tests)
+}
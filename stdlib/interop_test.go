@@ -0,0 +1,93 @@
+package stdlib_test
+
+import (
+	"testing"
+)
+
+func TestInterop(t *testing.T) {
+	tests := []struct{ input, output string }{
+		{
+			input:  "(.get (new Map [[1 2]]) 1)",
+			output: "2",
+		},
+		{
+			input:  "(.-size (Set. [1 2 1]))",
+			output: "2",
+		},
+		{
+			input:  "(.toUpperCase (.trim \" rem \"))",
+			output: "\"REM\"",
+		},
+		{
+			input:  "(js/Math.max 1 2)",
+			output: "2",
+		},
+		{
+			input:  "(js-obj :a 1 \"b\" [2])",
+			output: "{\"a\" 1 \"b\" [2]}",
+		},
+		{
+			input:  "(do (var o (js-obj)) (set! (.-a o) 1) (.-a o))",
+			output: "1",
+		},
+		{
+			input:  "(do (var o (js-obj :n 2)) (set! (.-double o) (fn [] (* this.n 2))) (.double o))",
+			output: "4",
+		},
+		{
+			input:  "(doto (js-array) (.push 1) (.push 2))",
+			output: "[1 2]",
+		},
+		{
+			input:  "(Array.isArray (js-array))",
+			output: "true",
+		},
+		{
+			input:  "(js* \"~{} instanceof ~{}\" (new Map) Map)",
+			output: "true",
+		},
+		{
+			input:  "(js* \"typeof ~{}\" (do 1 \"s\"))",
+			output: "\"string\"",
+		},
+		{
+			input:  "((fn [n] (do (js* \"if (~{} > 1) { return 'big' }\" n) \"small\")) 2)",
+			output: "\"big\"",
+		},
+		{
+			input:  "(clj->js {:a [1 :b]})",
+			output: "{\"a\" [1 \"b\"]}",
+		},
+		{
+			input:  "(:a (js->clj (js-obj \"a\" [1]) :keywordize-keys true))",
+			output: "[1]",
+		},
+	}
+	expectRem(t, tests)
+}
+
+func TestAwait(t *testing.T) {
+	tests := []struct{ input, output string }{
+		{
+			input:  "(var calls 0) calls",
+			output: "0",
+		},
+		{
+			input:  "(set calls (+ calls 1)) (await (or nil (.resolve Promise calls)))",
+			output: "1",
+		},
+		{
+			input:  "calls",
+			output: "1",
+		},
+		{
+			input:  "(var s (await (.resolve Promise \"let x = 1\"))) s",
+			output: "\"let x = 1\"",
+		},
+		{
+			input:  "(length s)",
+			output: "9",
+		},
+	}
+	expectRem(t, tests)
+}
@@ -0,0 +1,75 @@
+package stdlib_test
+
+import (
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	tests := []struct{ input, output string }{
+		{
+			input:  "(vec (take 3 (iterate #(* % 2) 1)))",
+			output: "[1 2 4]",
+		},
+		{
+			input:  "(vec (take 3 (repeat :a)))",
+			output: "[:a :a :a]",
+		},
+		{
+			input:  "(vec (take 5 (cycle [1 2])))",
+			output: "[1 2 1 2 1]",
+		},
+		{
+			input:  "(vec (take 0 (repeat 1)))",
+			output: "[]",
+		},
+		{
+			input:  "(vec (take 3 (lazy-map inc (iterate inc 0))))",
+			output: "[1 2 3]",
+		},
+		{
+			input:  "(vec (take 2 (lazy-filter even? (iterate inc 1))))",
+			output: "[2 4]",
+		},
+		{
+			input:  "(vec (lazy-filter identity [0 \"\" nil false 1]))",
+			output: "[0 \"\" 1]",
+		},
+		{
+			input:  "(vec (lazy-map first (new Map [[:a 1] [:b 2]])))",
+			output: "[:a :b]",
+		},
+		{
+			input:  "(vec (lazy-filter odd? (new Set [1 2 3 1])))",
+			output: "[1 3]",
+		},
+		{
+			input:  "(vec ((gen-fn [] (do (yield 1) (yield 2)))))",
+			output: "[1 2]",
+		},
+		{
+			input:  "(vec (take 4 (cycle ((gen-fn [] (do (yield 1) (yield 2)))))))",
+			output: "[1 2 1 2]",
+		},
+		{
+			input:  "(vec {\"a\" 1})",
+			output: "[[\"a\" 1]]",
+		},
+		{
+			input:  "(vec nil)",
+			output: "[]",
+		},
+		{
+			input:  "((fn [] (do (var n 0) (each [x (new Set [1 2 2])] (set n (+ n x))) n)))",
+			output: "3",
+		},
+		{
+			input:  "((fn [] (do (var ks []) (for [e (new Map [[:a 1] [:b 2]])] (ks.push (first e))) ks)))",
+			output: "[:a :b]",
+		},
+		{
+			input:  "((fn [] (do (var n 0) (each [x (take 3 (iterate inc 1))] (set n (+ n x))) n)))",
+			output: "6",
+		},
+	}
+	expectRem(t, tests)
+}
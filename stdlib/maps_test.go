@@ -0,0 +1,156 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	"github.com/fholmqvist/remlisp/transpiler"
+)
+
+func TestMaps(t *testing.T) {
+	tests := []struct{ input, output string }{
+		{
+			input:  "(reduce + [1 2 3])",
+			output: "6",
+		},
+		{
+			input:  "(reduce + 10 [1 2 3])",
+			output: "16",
+		},
+		{
+			input:  "(reduce (fn [acc [k v]] (+ acc v)) 0 {:a 1 :b 2})",
+			output: "3",
+		},
+		{
+			input:  "(keys {:a 1 :b 2})",
+			output: "[:a :b]",
+		},
+		{
+			input:  "(vals {:a 1 :b 2})",
+			output: "[1 2]",
+		},
+		{
+			input:  "(keys (hash-map :a 1))",
+			output: "[:a]",
+		},
+		{
+			input:  "(assoc {:a 1} :b 2)",
+			output: "{:a 1 :b 2}",
+		},
+		{
+			input:  "(dissoc {:a 1 :b 2} :a)",
+			output: "{:b 2}",
+		},
+		{
+			input:  "(merge {:a 1} {:b 2} {:a 3})",
+			output: "{:a 3 :b 2}",
+		},
+		{
+			input:  "(merge (hash-map :a 1) {:b 2})",
+			output: "{:a 1 :b 2}",
+		},
+		{
+			input:  "(merge-with + {:a 1} {:a 2 :b 1})",
+			output: "{:a 3 :b 1}",
+		},
+		{
+			input:  "(select-keys {:a 1 :b 2 :c 3} [:a :c :d])",
+			output: "{:a 1 :c 3}",
+		},
+		{
+			input:  "(update {:n 1} :n + 10)",
+			output: "{:n 11}",
+		},
+		{
+			input:  "(get-in {:a {:b 1}} [:a :b])",
+			output: "1",
+		},
+		{
+			input:  "(get-in {:a {:b 1}} [:a :c] :none)",
+			output: ":none",
+		},
+		{
+			input:  "(assoc-in {:a {:b 1}} [:a :c] 2)",
+			output: "{:a {:b 1 :c 2}}",
+		},
+		{
+			input:  "(update-in {:a {:n 1}} [:a :n] + 1 2)",
+			output: "{:a {:n 4}}",
+		},
+		{
+			input:  "(update-in (hash-map) [:a :n] (fn [n] (if n n 0)))",
+			output: "{:a {:n 0}}",
+		},
+		{
+			input:  "(group-by :kind [{:kind :a :v 1} {:kind :b :v 2} {:kind :a :v 3}])",
+			output: "{:a [{:kind :a :v 1} {:kind :a :v 3}] :b [{:kind :b :v 2}]}",
+		},
+		{
+			input:  "(frequencies [:a :b :a])",
+			output: "{:a 2 :b 1}",
+		},
+		{
+			input:  "(get (frequencies [1 1 2]) 1)",
+			output: "2",
+		},
+		{
+			input:  "(zipmap [:a :b] [1 2])",
+			output: "{:a 1 :b 2}",
+		},
+		{
+			input:  "(into {:a 1} [[:b 2]])",
+			output: "{:a 1 :b 2}",
+		},
+		{
+			input:  "(into [1] [2 3])",
+			output: "[1 2 3]",
+		},
+		{
+			input:  "(partition 2 [1 2 3 4 5])",
+			output: "[[1 2] [3 4]]",
+		},
+		{
+			input:  "(partition 2 1 [1 2 3])",
+			output: "[[1 2] [2 3]]",
+		},
+		{
+			input:  "(:b (merge {:a 1} {:b 2}))",
+			output: "2",
+		},
+		{
+			input:  "[(= (hash-map :a [1]) {:a [1]}) (= {:a [1]} (hash-map :a [1]))]",
+			output: "[true true]",
+		},
+		{
+			input:  "[(= NaN NaN) (!= NaN NaN) (= (/ 0 0) (/ 0 0))]",
+			output: "[false true false]",
+		},
+	}
+	expectRem(t, tests)
+}
+
+func TestMapAndSetPredicates(t *testing.T) {
+	tests := []struct{ input, output string }{
+		{
+			input:  "[(map? {:a 1}) (map? (hash-map :a 1)) (map? (js-obj))]",
+			output: "[true true true]",
+		},
+		{
+			input:  "[(map? [1]) (map? :a) (map? 'a) (map? nil) (map? (new Set))]",
+			output: "[false false false false false]",
+		},
+		{
+			input:  "[(set? (new Set [1])) (set? (hash-set 1))]",
+			output: "[true true]",
+		},
+		{
+			input:  "[(set? [1]) (set? {:a 1}) (set? nil)]",
+			output: "[false false false]",
+		},
+	}
+	t.Run("default", func(t *testing.T) {
+		expectRem(t, tests)
+	})
+	t.Run("persistent", func(t *testing.T) {
+		expectRemWith(t, transpiler.Options{Persistent: true}, tests)
+	})
+}
@@ -0,0 +1,211 @@
+package stdlib
+
+import (
+	"bytes"
+	"slices"
+)
+
+// Strips comments and whitespace from JavaScript.
+// Strings, template literals and regular expressions
+// are kept as they are, and newlines are kept where
+// a semicolon could be inserted in their place.
+//
+//	// add
+//	function add(a, b) {
+//	  return a + b
+//	}
+//
+//	=> function add(a,b){return a+b}
+func Minify(src []byte) []byte {
+	m := minifier{src: src, out: make([]byte, 0, len(src)/2)}
+	m.code()
+	return m.out
+}
+
+type minifier struct {
+	src []byte
+	out []byte
+	i   int
+
+	// The braces opened in each ${} of the
+	// template literals that are being read.
+	braces []int
+
+	// Whitespace before the next token,
+	// which is a newline if it had one.
+	space byte
+}
+
+func (m *minifier) code() {
+	for m.i < len(m.src) {
+		ch := m.src[m.i]
+		switch {
+		case ch == '\n':
+			m.space = '\n'
+			m.i++
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			if m.space == 0 {
+				m.space = ' '
+			}
+			m.i++
+		case ch == '/' && m.peek(1) == '/':
+			for m.i < len(m.src) && m.src[m.i] != '\n' {
+				m.i++
+			}
+		case ch == '/' && m.peek(1) == '*':
+			end := bytes.Index(m.src[m.i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(m.src) - m.i - 4
+			}
+			if bytes.IndexByte(m.src[m.i:m.i+end+4], '\n') >= 0 {
+				m.space = '\n'
+			} else if m.space == 0 {
+				m.space = ' '
+			}
+			m.i += end + 4
+		case ch == '\'' || ch == '"':
+			m.token(ch)
+			m.quoted(ch)
+		case ch == '`':
+			m.token(ch)
+			m.template()
+		case ch == '/' && m.regexAllowed():
+			m.token(ch)
+			m.regex()
+		case ch == '{' && len(m.braces) > 0:
+			m.braces[len(m.braces)-1]++
+			m.token(ch)
+			m.out = append(m.out, ch)
+			m.i++
+		case ch == '}' && len(m.braces) > 0:
+			if m.braces[len(m.braces)-1] == 0 {
+				m.braces = m.braces[:len(m.braces)-1]
+				m.token(ch)
+				m.template()
+				continue
+			}
+			m.braces[len(m.braces)-1]--
+			m.token(ch)
+			m.out = append(m.out, ch)
+			m.i++
+		default:
+			m.token(ch)
+			m.out = append(m.out, ch)
+			m.i++
+		}
+	}
+}
+
+// Writes the whitespace that is needed
+// between the last token and the next.
+func (m *minifier) token(next byte) {
+	space := m.space
+	m.space = 0
+	if space == 0 || len(m.out) == 0 {
+		return
+	}
+	last := m.out[len(m.out)-1]
+	if space == '\n' {
+		if bytes.IndexByte([]byte("{([,;"), last) < 0 &&
+			bytes.IndexByte([]byte(")]}."), next) < 0 {
+			m.out = append(m.out, '\n')
+			return
+		}
+	}
+	if isWord(last) && isWord(next) ||
+		last == next && (last == '+' || last == '-') ||
+		last == '/' || next == '/' {
+		m.out = append(m.out, ' ')
+	}
+}
+
+func (m *minifier) quoted(quote byte) {
+	start := m.i
+	for m.i++; m.i < len(m.src) && m.src[m.i] != quote && m.src[m.i] != '\n'; m.i++ {
+		if m.src[m.i] == '\\' {
+			m.i++
+		}
+	}
+	m.i++
+	m.out = append(m.out, m.src[start:min(m.i, len(m.src))]...)
+}
+
+// Reads the template literal from its start
+// or the end of a ${}, up to its end or the
+// next ${.
+func (m *minifier) template() {
+	start := m.i
+	for m.i++; m.i < len(m.src); m.i++ {
+		switch m.src[m.i] {
+		case '\\':
+			m.i++
+		case '`':
+			m.i++
+			m.out = append(m.out, m.src[start:m.i]...)
+			return
+		case '$':
+			if m.peek(1) == '{' {
+				m.i += 2
+				m.out = append(m.out, m.src[start:m.i]...)
+				m.braces = append(m.braces, 0)
+				return
+			}
+		}
+	}
+	m.out = append(m.out, m.src[start:]...)
+}
+
+func (m *minifier) regex() {
+	start := m.i
+	class := false
+	for m.i++; m.i < len(m.src) && m.src[m.i] != '\n'; m.i++ {
+		ch := m.src[m.i]
+		if ch == '\\' {
+			m.i++
+		} else if ch == '[' {
+			class = true
+		} else if ch == ']' {
+			class = false
+		} else if ch == '/' && !class {
+			break
+		}
+	}
+	for m.i++; m.i < len(m.src) && isWord(m.src[m.i]); m.i++ {
+	}
+	m.out = append(m.out, m.src[start:min(m.i, len(m.src))]...)
+}
+
+// Whether a / starts a regular expression rather
+// than dividing, which it does where a value can't
+// have ended.
+func (m *minifier) regexAllowed() bool {
+	if len(m.out) == 0 {
+		return true
+	}
+	last := m.out[len(m.out)-1]
+	if bytes.IndexByte([]byte(")]}'\"`"), last) >= 0 {
+		return false
+	}
+	if !isWord(last) {
+		return true
+	}
+	end := len(m.out)
+	start := end
+	for start > 0 && isWord(m.out[start-1]) {
+		start--
+	}
+	return slices.Contains([]string{"return", "typeof", "case", "do", "else", "in", "of", "new", "void", "throw", "yield", "await", "delete"},
+		string(m.out[start:end]))
+}
+
+func (m *minifier) peek(n int) byte {
+	if m.i+n < len(m.src) {
+		return m.src[m.i+n]
+	}
+	return 0
+}
+
+func isWord(ch byte) bool {
+	return ch == '_' || ch == '$' || ch >= 'a' && ch <= 'z' ||
+		ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch > 127
+}
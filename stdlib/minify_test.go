@@ -0,0 +1,50 @@
+package stdlib_test
+
+import (
+	"testing"
+
+	h "github.com/fholmqvist/remlisp/highlight"
+	"github.com/fholmqvist/remlisp/stdlib"
+)
+
+func TestMinify(t *testing.T) {
+	tests := []struct{ input, output string }{
+		{
+			input:  "// add\nfunction add(a, b) {\n  return a + b\n}\n",
+			output: "function add(a,b){return a+b}",
+		},
+		{
+			input:  "/* a\n   b */ const x = 1 /* c */ + 2",
+			output: "const x=1+2",
+		},
+		{
+			input:  "f(a + +b, a - -b, a / b)",
+			output: "f(a+ +b,a- -b,a / b)",
+		},
+		{
+			input:  "const s = '// not  a comment', t = \"/* nor */\"",
+			output: "const s='// not  a comment',t=\"/* nor */\"",
+		},
+		{
+			input:  "const s = `${a  +  `${ {b: 1}.b }`} // c`",
+			output: "const s=`${a+`${{b:1}.b}`} // c`",
+		},
+		{
+			input:  "if (!/^ a//[/]/.test(s)) return /x/g",
+			output: "if(!/^ a//[/]/.test(s))return /x/g",
+		},
+		{
+			input:  "x = a\n(b)\ny = [\n  1,\n  2\n]\n  .length",
+			output: "x=a\n(b)\ny=[1,2].length",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := string(stdlib.Minify([]byte(tt.input)))
+			if got != tt.output {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n",
+					h.Code(tt.output), h.Code(got))
+			}
+		})
+	}
+}
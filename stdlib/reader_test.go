@@ -0,0 +1,135 @@
+package stdlib_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	ex "github.com/fholmqvist/remlisp/expr"
+	h "github.com/fholmqvist/remlisp/highlight"
+	"github.com/fholmqvist/remlisp/lexer"
+	"github.com/fholmqvist/remlisp/parser"
+	"github.com/fholmqvist/remlisp/pp"
+	"github.com/fholmqvist/remlisp/transpiler"
+)
+
+// The JS reader should read the same data that
+// quoting the Go lexer/parser output produces.
+func TestReaderParity(t *testing.T) {
+	tests := []string{
+		"nil",
+		"0",
+		"1234",
+		"-1234",
+		"0.0",
+		"1234.0",
+		"-1234.0",
+		"true",
+		"false",
+		"example_identifier",
+		"true?",
+		"send!",
+		"\"example_string\"",
+		":atom",
+		";; comments are ignored\n1",
+		"#| block #| comments |# nest |# 1",
+		"%",
+		"=",
+		"!=",
+		"<=",
+		">",
+		">=",
+		"()",
+		"(1 2 3 4)",
+		"[1 2 3 4]",
+		"{:a 2 :b 4}",
+		"(fn add [x y] (+ x y))",
+		"(fn id-array [& x] \"Id function for arrays only.\" x)",
+		"(. (Array 10) (fill 1) (map (fn [_ i] i)))",
+		"(if (< 1 2) 1 2)",
+		"(do 1 2 3)",
+		"(var x 1)",
+		"(set x 2)",
+		"(get {:a 1} :a)",
+		"(while (< 1 2) (println \"infinite loop!\"))",
+		"'(set x 2)",
+		"`(set x 2)",
+		",1",
+		"`(a ,b ,@c)",
+		"(macro inc [n] (+ n 1))",
+		"(+ 1 #_ 2 3)",
+		"[1 #_ #_ 2 3 4]",
+		"{:a 1 #_ :b #_ 2}",
+		"#_ (println \"ignored\") '#_ x y",
+		"1 2\t3\r\n4",
+	}
+	rt := newRuntime(t)
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			expected := send(t, rt, quoted(t, tt))
+			got := send(t, rt, fmt.Sprintf("_readAll(%q)", tt))
+			if expected != got {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n",
+					h.Code(expected), h.Code(got))
+			}
+		})
+	}
+}
+
+func TestReaderErrorParity(t *testing.T) {
+	tests := []string{
+		")",
+		"(",
+		"(1",
+		"{:a}",
+		"#| unterminated",
+		"'",
+	}
+	rt := newRuntime(t)
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			if _, err := read(tt); err == nil {
+				t.Fatal("expected Go reader error, got nil")
+			}
+			out, erre := rt.Send(fmt.Sprintf("_readAll(%q)", tt))
+			if erre != nil {
+				t.Fatal(erre)
+			}
+			if _, err := pp.ParseResponseRaw(nil, out); err == nil {
+				t.Fatalf("expected JS reader error, got %s", out)
+			}
+		})
+	}
+}
+
+// Every form in the input, quoted and transpiled.
+func quoted(t *testing.T, input string) string {
+	exprs, erre := read(input)
+	if erre != nil {
+		t.Fatalf("\n\n%s:\n\n%v\n\n", h.Bold("error"), erre)
+	}
+	trn := transpiler.New()
+	forms := make([]string, len(exprs))
+	for i, expr := range exprs {
+		js, erre := trn.TranspileOne(&ex.Quote{E: expr, P: expr.Pos()})
+		if erre != nil {
+			t.Fatal(erre)
+		}
+		// Forms are statements, and these are elements.
+		forms[i] = strings.TrimSuffix(js, ";")
+	}
+	return fmt.Sprintf("[%s]", strings.Join(forms, ", "))
+}
+
+func read(input string) ([]ex.Expr, error) {
+	lex := lexer.New()
+	tokens, erre := lex.LexString(input)
+	if erre != nil {
+		return nil, fmt.Errorf("%s", erre.Msg)
+	}
+	exprs, erre := parser.New(lex).Parse(tokens)
+	if erre != nil {
+		return nil, fmt.Errorf("%s", erre.Msg)
+	}
+	return exprs, nil
+}
@@ -0,0 +1,1077 @@
+// ============================================================================
+// COLLECTIONS
+// ============================================================================
+
+// Persistent vectors, hash maps and sets, which
+// are never changed in place. Updates share most
+// of their structure with the original, so conj,
+// assoc and dissoc are close to constant time.
+//
+// Vectors are 32-way tries with a tail, like in
+// Clojure, and hash maps are hash array mapped
+// tries (HAMT) that use _hash and _equals, so any
+// value can be a key.
+//
+// The generic functions, _conj, _assoc and so on,
+// also work with arrays, objects, Maps and Sets,
+// which are copied instead of changed. Objects
+// keep atom keys as ":name", which the functions
+// that read keys turn back into atoms.
+
+const {
+  _vector,
+  _isVector,
+  _hashMap,
+  _isHashMap,
+  _hashSet,
+  _isHashSet,
+  _isObject,
+  _conj,
+  _assoc,
+  _dissoc,
+  _get,
+  _update,
+  _getIn,
+  _assocIn,
+  _reduce,
+  _keys,
+  _vals,
+  _merge,
+  _mergeWith,
+  _selectKeys,
+  _updateIn,
+  _groupBy,
+  _frequencies,
+  _zipmap,
+  _into,
+  _partition,
+  _toJS,
+  _fromJS,
+  _jsObj,
+  _reverse,
+  _shuffle,
+} = (() => {
+  const BITS = 5
+  const WIDTH = 1 << BITS
+  const MASK = WIDTH - 1
+
+  const EQUALS = Symbol.for('remlisp.equals')
+  const HASH = Symbol.for('remlisp.hash')
+  const LOOKUP = Symbol.for('remlisp.lookup')
+
+  const NOT_FOUND = Symbol('not found')
+
+  // ==========================================================================
+  // Vectors
+  // ==========================================================================
+
+  class Vector {
+    constructor(size, shift, root, tail) {
+      this.size = size
+      this.shift = shift
+      this.root = root
+      this.tail = tail
+      Object.freeze(this)
+    }
+
+    get length() {
+      return this.size
+    }
+
+    // Where the tail starts, everything before
+    // it is in the trie.
+    tailOffset() {
+      return this.size < WIDTH ? 0 : ((this.size - 1) >>> BITS) << BITS
+    }
+
+    // The leaf that holds index i.
+    leafFor(i) {
+      if (i >= this.tailOffset()) {
+        return this.tail
+      }
+      let node = this.root
+      for (let level = this.shift; level > 0; level -= BITS) {
+        node = node[(i >>> level) & MASK]
+      }
+      return node
+    }
+
+    get(i, notFound) {
+      if (!Number.isInteger(i) || i < 0 || i >= this.size) {
+        return notFound
+      }
+      return this.leafFor(i)[i & MASK]
+    }
+
+    has(i) {
+      return Number.isInteger(i) && i >= 0 && i < this.size
+    }
+
+    conj(x) {
+      if (this.size - this.tailOffset() < WIDTH) {
+        return new Vector(this.size + 1, this.shift, this.root, [...this.tail, x])
+      }
+      // The tail is full, so it moves into the trie,
+      // which grows a level when the root is full.
+      if (this.size >>> BITS > 1 << this.shift) {
+        const root = [this.root, newPath(this.shift, this.tail)]
+        return new Vector(this.size + 1, this.shift + BITS, root, [x])
+      }
+      const root = pushTail(this.size, this.shift, this.root, this.tail)
+      return new Vector(this.size + 1, this.shift, root, [x])
+    }
+
+    assoc(i, x) {
+      if (i === this.size) {
+        return this.conj(x)
+      }
+      if (!this.has(i)) {
+        throw new RangeError(`index out of bounds: ${i}`)
+      }
+      if (i >= this.tailOffset()) {
+        const tail = this.tail.slice()
+        tail[i & MASK] = x
+        return new Vector(this.size, this.shift, this.root, tail)
+      }
+      const root = assocPath(this.shift, this.root, i, x)
+      return new Vector(this.size, this.shift, root, this.tail)
+    }
+
+    *[Symbol.iterator]() {
+      for (let i = 0; i < this.size; i += WIDTH) {
+        yield* this.leafFor(i)
+      }
+    }
+
+    toArray() {
+      return [...this]
+    }
+
+    toJSON() {
+      return this.toArray()
+    }
+
+    toString() {
+      return `[${this.toArray().join(' ')}]`
+    }
+
+    [LOOKUP](k, notFound) {
+      return this.get(k, notFound)
+    }
+
+    // Vectors are equal to arrays and vectors
+    // with equal items.
+    [EQUALS](other) {
+      if (!Array.isArray(other) && !(other instanceof Vector)) {
+        return false
+      }
+      if (other.length !== this.size) {
+        return false
+      }
+      let i = 0
+      for (const x of other) {
+        if (!_equals(this.get(i++), x)) {
+          return false
+        }
+      }
+      return true
+    }
+
+    // The same as for arrays.
+    [HASH]() {
+      let h = 1
+      for (const x of this) {
+        h = (Math.imul(31, h) + _hash(x)) | 0
+      }
+      return h
+    }
+  }
+
+  const EMPTY_VECTOR = new Vector(0, BITS, [], [])
+
+  function newPath(level, node) {
+    return level === 0 ? node : [newPath(level - BITS, node)]
+  }
+
+  function pushTail(size, level, parent, tail) {
+    const i = ((size - 1) >>> level) & MASK
+    const node = parent.slice()
+    if (level === BITS) {
+      node[i] = tail
+    } else if (parent[i]) {
+      node[i] = pushTail(size, level - BITS, parent[i], tail)
+    } else {
+      node[i] = newPath(level - BITS, tail)
+    }
+    return node
+  }
+
+  function assocPath(level, node, i, x) {
+    const copy = node.slice()
+    if (level === 0) {
+      copy[i & MASK] = x
+    } else {
+      const j = (i >>> level) & MASK
+      copy[j] = assocPath(level - BITS, node[j], i, x)
+    }
+    return copy
+  }
+
+  // The array methods that don't change the array,
+  // so that vectors work with code for arrays.
+  for (const name of ['map', 'filter', 'slice', 'flat', 'flatMap', 'toReversed', 'toSorted']) {
+    Vector.prototype[name] = function (...args) {
+      return fromArray(this.toArray()[name](...args))
+    }
+  }
+  for (const name of ['at', 'every', 'find', 'findIndex', 'forEach', 'includes', 'indexOf', 'join', 'reduce', 'some']) {
+    Vector.prototype[name] = function (...args) {
+      return this.toArray()[name](...args)
+    }
+  }
+
+  function fromArray(xs) {
+    let v = EMPTY_VECTOR
+    for (const x of xs) {
+      v = v.conj(x)
+    }
+    return v
+  }
+
+  function _vector(...xs) {
+    return fromArray(xs)
+  }
+
+  function _isVector(x) {
+    return x instanceof Vector
+  }
+
+  // ==========================================================================
+  // Hash maps
+  // ==========================================================================
+
+  // Nodes hold up to 32 slots, picked by five bits
+  // of the hash at a time. A slot is either an
+  // entry, [key, value], or a node one level down.
+  class BitmapNode {
+    constructor(bitmap, slots) {
+      this.bitmap = bitmap
+      this.slots = slots
+    }
+
+    find(shift, hash, key, notFound) {
+      const bit = bitpos(hash, shift)
+      if ((this.bitmap & bit) === 0) {
+        return notFound
+      }
+      const slot = this.slots[index(this.bitmap, bit)]
+      if (isNode(slot)) {
+        return slot.find(shift + BITS, hash, key, notFound)
+      }
+      return _equals(slot[0], key) ? slot[1] : notFound
+    }
+
+    assoc(shift, hash, key, value, added) {
+      const bit = bitpos(hash, shift)
+      const i = index(this.bitmap, bit)
+      if ((this.bitmap & bit) === 0) {
+        added.value = true
+        const slots = this.slots.slice()
+        slots.splice(i, 0, [key, value])
+        return new BitmapNode(this.bitmap | bit, slots)
+      }
+      const slot = this.slots[i]
+      let next
+      if (isNode(slot)) {
+        next = slot.assoc(shift + BITS, hash, key, value, added)
+        if (next === slot) {
+          return this
+        }
+      } else if (_equals(slot[0], key)) {
+        if (slot[1] === value) {
+          return this
+        }
+        next = [slot[0], value]
+      } else {
+        added.value = true
+        next = split(shift + BITS, slot, hash, key, value)
+      }
+      const slots = this.slots.slice()
+      slots[i] = next
+      return new BitmapNode(this.bitmap, slots)
+    }
+
+    // Returns null when the node becomes empty.
+    without(shift, hash, key) {
+      const bit = bitpos(hash, shift)
+      if ((this.bitmap & bit) === 0) {
+        return this
+      }
+      const i = index(this.bitmap, bit)
+      const slot = this.slots[i]
+      if (isNode(slot)) {
+        const next = slot.without(shift + BITS, hash, key)
+        if (next === slot) {
+          return this
+        }
+        if (next !== null) {
+          const slots = this.slots.slice()
+          slots[i] = next
+          return new BitmapNode(this.bitmap, slots)
+        }
+      } else if (!_equals(slot[0], key)) {
+        return this
+      }
+      if (this.bitmap === bit) {
+        return null
+      }
+      const slots = this.slots.slice()
+      slots.splice(i, 1)
+      return new BitmapNode(this.bitmap ^ bit, slots)
+    }
+
+    *entries() {
+      for (const slot of this.slots) {
+        if (isNode(slot)) {
+          yield* slot.entries()
+        } else {
+          yield slot
+        }
+      }
+    }
+  }
+
+  // Entries whose keys have the same hash.
+  class CollisionNode {
+    constructor(hash, entries) {
+      this.hash = hash
+      this.entries_ = entries
+    }
+
+    indexOf(key) {
+      return this.entries_.findIndex(([k]) => _equals(k, key))
+    }
+
+    find(_shift, _hash, key, notFound) {
+      const i = this.indexOf(key)
+      return i === -1 ? notFound : this.entries_[i][1]
+    }
+
+    assoc(shift, hash, key, value, added) {
+      if (hash !== this.hash) {
+        const node = new BitmapNode(bitpos(this.hash, shift), [this])
+        return node.assoc(shift, hash, key, value, added)
+      }
+      const i = this.indexOf(key)
+      if (i === -1) {
+        added.value = true
+        return new CollisionNode(hash, [...this.entries_, [key, value]])
+      }
+      if (this.entries_[i][1] === value) {
+        return this
+      }
+      const entries = this.entries_.slice()
+      entries[i] = [entries[i][0], value]
+      return new CollisionNode(hash, entries)
+    }
+
+    without(_shift, _hash, key) {
+      const i = this.indexOf(key)
+      if (i === -1) {
+        return this
+      }
+      if (this.entries_.length === 1) {
+        return null
+      }
+      return new CollisionNode(this.hash, this.entries_.filter((_, j) => j !== i))
+    }
+
+    *entries() {
+      yield* this.entries_
+    }
+  }
+
+  const EMPTY_NODE = new BitmapNode(0, [])
+
+  function isNode(x) {
+    return x instanceof BitmapNode || x instanceof CollisionNode
+  }
+
+  function bitpos(hash, shift) {
+    return 1 << ((hash >>> shift) & MASK)
+  }
+
+  function index(bitmap, bit) {
+    return popcount(bitmap & (bit - 1))
+  }
+
+  function popcount(x) {
+    x -= (x >>> 1) & 0x55555555
+    x = (x & 0x33333333) + ((x >>> 2) & 0x33333333)
+    x = (x + (x >>> 4)) & 0x0f0f0f0f
+    return Math.imul(x, 0x01010101) >>> 24
+  }
+
+  // Two entries that share a slot, as a new node.
+  function split(shift, entry, hash, key, value) {
+    const entryHash = _hash(entry[0])
+    if (entryHash === hash) {
+      return new CollisionNode(hash, [entry, [key, value]])
+    }
+    const added = { value: false }
+    return EMPTY_NODE
+      .assoc(shift, entryHash, entry[0], entry[1], added)
+      .assoc(shift, hash, key, value, added)
+  }
+
+  class HashMap {
+    constructor(size, root) {
+      this.size = size
+      this.root = root
+      Object.freeze(this)
+    }
+
+    get(key, notFound) {
+      return this.root.find(0, _hash(key), key, notFound)
+    }
+
+    has(key) {
+      return this.get(key, NOT_FOUND) !== NOT_FOUND
+    }
+
+    assoc(key, value) {
+      const added = { value: false }
+      const root = this.root.assoc(0, _hash(key), key, value, added)
+      if (root === this.root) {
+        return this
+      }
+      return new HashMap(added.value ? this.size + 1 : this.size, root)
+    }
+
+    dissoc(key) {
+      const root = this.root.without(0, _hash(key), key)
+      if (root === this.root) {
+        return this
+      }
+      return new HashMap(this.size - 1, root ?? EMPTY_NODE)
+    }
+
+    *[Symbol.iterator]() {
+      for (const [k, v] of this.root.entries()) {
+        yield [k, v]
+      }
+    }
+
+    *keys() {
+      for (const [k] of this.root.entries()) {
+        yield k
+      }
+    }
+
+    *values() {
+      for (const [, v] of this.root.entries()) {
+        yield v
+      }
+    }
+
+    // Atoms turn into ":name" as keys,
+    // like in object literals.
+    toJSON() {
+      const obj = {}
+      for (const [k, v] of this) {
+        obj[String(k)] = v
+      }
+      return obj
+    }
+
+    [LOOKUP](k, notFound) {
+      return this.get(k, notFound)
+    }
+
+    // Hash maps are equal to hash maps and objects
+    // with equal entries, like vectors and arrays.
+    // Objects only have string keys, and ":name"
+    // for atoms.
+    [EQUALS](other) {
+      if (isObject(other)) {
+        return equalsObject(this, other)
+      }
+      if (!(other instanceof HashMap) || other.size !== this.size) {
+        return false
+      }
+      for (const [k, v] of this) {
+        const w = other.get(k, NOT_FOUND)
+        if (w === NOT_FOUND || !_equals(v, w)) {
+          return false
+        }
+      }
+      return true
+    }
+
+    // The same as for Maps and objects.
+    [HASH]() {
+      return _hash(new Map(this))
+    }
+  }
+
+  const EMPTY_MAP = new HashMap(0, EMPTY_NODE)
+
+  function equalsObject(m, obj) {
+    if (Object.keys(obj).length !== m.size) {
+      return false
+    }
+    const seen = new Set()
+    for (const [k, v] of m) {
+      if (typeof k !== 'string' && !_isAtom(k)) {
+        return false
+      }
+      const key = String(k)
+      if (seen.has(key) || !Object.hasOwn(obj, key) || !_equals(v, obj[key])) {
+        return false
+      }
+      seen.add(key)
+    }
+    return true
+  }
+
+  // Properties that aren't methods read through to
+  // the keys, where ":name" is an atom, so that
+  // destructuring works like with objects.
+  //
+  //   (fn f [{:keys [a]}] a) (f (hash-map :a 1))
+  Object.setPrototypeOf(
+    HashMap.prototype,
+    new Proxy(Object.prototype, {
+      get(target, key, receiver) {
+        if (typeof key === 'string') {
+          let v = receiver.get(propertyKey(key), NOT_FOUND)
+          // Numbers are strings as properties.
+          if (v === NOT_FOUND && key !== '' && !isNaN(key)) {
+            v = receiver.get(Number(key), NOT_FOUND)
+          }
+          if (v !== NOT_FOUND) {
+            return v
+          }
+        }
+        return Reflect.get(target, key, receiver)
+      },
+    }),
+  )
+
+  function _hashMap(...kvs) {
+    if (kvs.length % 2 !== 0) {
+      throw new TypeError(`expected value for key: ${kvs[kvs.length - 1]}`)
+    }
+    let m = EMPTY_MAP
+    for (let i = 0; i < kvs.length; i += 2) {
+      m = m.assoc(kvs[i], kvs[i + 1])
+    }
+    return m
+  }
+
+  function _isHashMap(x) {
+    return x instanceof HashMap
+  }
+
+  // ==========================================================================
+  // Sets
+  // ==========================================================================
+
+  class HashSet {
+    constructor(map) {
+      this.map = map
+      Object.freeze(this)
+    }
+
+    get size() {
+      return this.map.size
+    }
+
+    get(x, notFound) {
+      return this.map.get(x, notFound)
+    }
+
+    has(x) {
+      return this.map.has(x)
+    }
+
+    conj(x) {
+      const map = this.map.assoc(x, x)
+      return map === this.map ? this : new HashSet(map)
+    }
+
+    disj(x) {
+      const map = this.map.dissoc(x)
+      return map === this.map ? this : new HashSet(map)
+    }
+
+    [Symbol.iterator]() {
+      return this.map.keys()
+    }
+
+    // Printed as #{...}.
+    toJSON() {
+      return { $set: [...this] }
+    }
+
+    [LOOKUP](k, notFound) {
+      return this.get(k, notFound)
+    }
+
+    [EQUALS](other) {
+      if (!(other instanceof HashSet) || other.size !== this.size) {
+        return false
+      }
+      for (const x of this) {
+        if (!other.has(x)) {
+          return false
+        }
+      }
+      return true
+    }
+
+    // The same as for Sets.
+    [HASH]() {
+      let h = 0
+      for (const x of this) {
+        h = (h + _hash(x)) | 0
+      }
+      return h
+    }
+  }
+
+  const EMPTY_SET = new HashSet(EMPTY_MAP)
+
+  function _hashSet(...xs) {
+    let s = EMPTY_SET
+    for (const x of xs) {
+      s = s.conj(x)
+    }
+    return s
+  }
+
+  function _isHashSet(x) {
+    return x instanceof HashSet
+  }
+
+  // ==========================================================================
+  // Generic functions
+  // ==========================================================================
+
+  function isObject(x) {
+    if (x === null || typeof x !== 'object') {
+      return false
+    }
+    const proto = Object.getPrototypeOf(x)
+    return proto === null || proto === Object.prototype
+  }
+
+  // Adds xs the way that fits the collection,
+  // maps take [key value] entries.
+  //
+  //   (conj [1] 2)       => [1 2]
+  //   (conj {:a 1} [:b 2]) => {:a 1 :b 2}
+  function _conj(coll, ...xs) {
+    if (coll == null) {
+      return xs
+    }
+    if (coll instanceof Vector || coll instanceof HashSet) {
+      return xs.reduce((c, x) => c.conj(x), coll)
+    }
+    if (coll instanceof HashMap) {
+      return xs.reduce((m, [k, v]) => m.assoc(k, v), coll)
+    }
+    if (Array.isArray(coll)) {
+      return [...coll, ...xs]
+    }
+    if (coll instanceof Set) {
+      return new Set([...coll, ...xs])
+    }
+    // Entries can be vectors, which don't have
+    // the indexes that Map and fromEntries read.
+    const entries = xs.map(([k, v]) => [k, v])
+    if (coll instanceof Map) {
+      return new Map([...coll, ...entries])
+    }
+    if (isObject(coll)) {
+      return { ...coll, ...Object.fromEntries(entries) }
+    }
+    throw new TypeError(`can't conj onto ${coll}`)
+  }
+
+  //   (assoc [1 2] 0 3)  => [3 2]
+  //   (assoc {:a 1} :b 2) => {:a 1 :b 2}
+  function _assoc(coll, ...kvs) {
+    if (kvs.length % 2 !== 0) {
+      throw new TypeError(`expected value for key: ${kvs[kvs.length - 1]}`)
+    }
+    for (let i = 0; i < kvs.length; i += 2) {
+      coll = assoc1(coll, kvs[i], kvs[i + 1])
+    }
+    return coll
+  }
+
+  function assoc1(coll, k, v) {
+    if (coll == null) {
+      return { [k]: v }
+    }
+    if (coll instanceof Vector || coll instanceof HashMap) {
+      return coll.assoc(k, v)
+    }
+    if (Array.isArray(coll)) {
+      if (!Number.isInteger(k) || k < 0 || k > coll.length) {
+        throw new RangeError(`index out of bounds: ${k}`)
+      }
+      const copy = coll.slice()
+      copy[k] = v
+      return copy
+    }
+    if (coll instanceof Map) {
+      return new Map(coll).set(k, v)
+    }
+    return { ...coll, [k]: v }
+  }
+
+  function _dissoc(coll, ...ks) {
+    if (coll == null) {
+      return coll
+    }
+    if (coll instanceof HashMap) {
+      return ks.reduce((m, k) => m.dissoc(k), coll)
+    }
+    if (coll instanceof Map) {
+      const copy = new Map(coll)
+      ks.forEach((k) => copy.delete(k))
+      return copy
+    }
+    const copy = { ...coll }
+    ks.forEach((k) => delete copy[k])
+    return copy
+  }
+
+  // Looks up k in any collection, or returns
+  // notFound (nil) when it isn't there.
+  function _get(coll, k, notFound = null) {
+    if (coll == null) {
+      return notFound
+    }
+    if (coll instanceof Vector || coll instanceof HashMap || coll instanceof HashSet) {
+      return coll.get(k, notFound)
+    }
+    if (coll instanceof Map) {
+      return coll.has(k) ? coll.get(k) : notFound
+    }
+    if (coll instanceof Set) {
+      return coll.has(k) ? k : notFound
+    }
+    if (Array.isArray(coll) || typeof coll === 'string') {
+      return Number.isInteger(k) && k >= 0 && k < coll.length ? coll[k] : notFound
+    }
+    return k in Object(coll) ? coll[k] : notFound
+  }
+
+  //   (update {:n 1} :n + 1) => {:n 2}
+  function _update(coll, k, f, ...args) {
+    return assoc1(coll, k, f(_get(coll, k), ...args))
+  }
+
+  //   (get-in {:a [1 2]} [:a 1]) => 2
+  function _getIn(coll, ks, notFound = null) {
+    for (const k of ks) {
+      coll = _get(coll, k, NOT_FOUND)
+      if (coll === NOT_FOUND) {
+        return notFound
+      }
+    }
+    return coll
+  }
+
+  //   (assoc-in {} [:a :b] 1) => {:a {:b 1}}
+  function _assocIn(coll, ks, v) {
+    const [k, ...rest] = ks
+    if (rest.length === 0) {
+      return assoc1(coll, k, v)
+    }
+    return assoc1(coll, k, _assocIn(_get(coll, k), rest, v))
+  }
+
+  // ==========================================================================
+  // Maps
+  // ==========================================================================
+
+  // Atoms turn into ":name" as object keys,
+  // so they are turned back when read.
+  function propertyKey(k) {
+    return k.startsWith(':') && k.length > 1 ? _atom(k.slice(1)) : k
+  }
+
+  // The [key value] entries of any map, and the
+  // items of anything else that is iterable.
+  function* seq(coll) {
+    if (coll == null) {
+      return
+    }
+    if (coll instanceof HashMap || coll instanceof Map) {
+      for (const [k, v] of coll) {
+        yield [k, v]
+      }
+    } else if (isObject(coll)) {
+      for (const k of Object.keys(coll)) {
+        yield [propertyKey(k), coll[k]]
+      }
+    } else {
+      yield* coll
+    }
+  }
+
+  // An empty collection of the same kind.
+  function empty(coll) {
+    if (coll instanceof HashMap) {
+      return EMPTY_MAP
+    }
+    if (coll instanceof Vector) {
+      return EMPTY_VECTOR
+    }
+    if (coll instanceof HashSet) {
+      return EMPTY_SET
+    }
+    if (coll instanceof Map) {
+      return new Map()
+    }
+    if (coll instanceof Set) {
+      return new Set()
+    }
+    if (Array.isArray(coll)) {
+      return []
+    }
+    return {}
+  }
+
+  //   (reduce + [1 2 3])   => 6
+  //   (reduce + 10 [1 2 3]) => 16
+  function _reduce(f, ...args) {
+    const [init, coll] = args.length === 1 ? [NOT_FOUND, args[0]] : args
+    let acc = init
+    for (const x of seq(coll)) {
+      acc = acc === NOT_FOUND ? x : f(acc, x)
+    }
+    return acc === NOT_FOUND ? f() : acc
+  }
+
+  function _keys(m) {
+    return [...seq(m)].map(([k]) => k)
+  }
+
+  function _vals(m) {
+    return [...seq(m)].map(([, v]) => v)
+  }
+
+  //   (merge {:a 1} {:b 2} {:a 3}) => {:a 3 :b 2}
+  function _merge(...ms) {
+    return _mergeWith((_, b) => b, ...ms)
+  }
+
+  //   (merge-with + {:a 1} {:a 2}) => {:a 3}
+  function _mergeWith(f, ...ms) {
+    ms = ms.filter((m) => m != null)
+    if (ms.length === 0) {
+      return null
+    }
+    let result = ms[0]
+    for (const m of ms.slice(1)) {
+      for (const [k, v] of seq(m)) {
+        const old = _get(result, k, NOT_FOUND)
+        result = assoc1(result, k, old === NOT_FOUND ? v : f(old, v))
+      }
+    }
+    return result
+  }
+
+  //   (select-keys {:a 1 :b 2} [:a]) => {:a 1}
+  function _selectKeys(m, ks) {
+    let result = empty(m)
+    for (const k of ks) {
+      const v = _get(m, k, NOT_FOUND)
+      if (v !== NOT_FOUND) {
+        result = assoc1(result, k, v)
+      }
+    }
+    return result
+  }
+
+  //   (update-in {:a {:n 1}} [:a :n] + 1) => {:a {:n 2}}
+  function _updateIn(m, ks, f, ...args) {
+    return _assocIn(m, ks, f(_getIn(m, ks), ...args))
+  }
+
+  // The maps that are built from scratch are hash
+  // maps, since their keys can be any value.
+
+  //   (group-by odd? [1 2 3]) => {true [1 3] false [2]}
+  function _groupBy(f, xs) {
+    let m = EMPTY_MAP
+    for (const x of seq(xs)) {
+      const k = f(x)
+      m = m.assoc(k, [...m.get(k, []), x])
+    }
+    return m
+  }
+
+  //   (frequencies [:a :b :a]) => {:a 2 :b 1}
+  function _frequencies(xs) {
+    let m = EMPTY_MAP
+    for (const x of seq(xs)) {
+      m = m.assoc(x, m.get(x, 0) + 1)
+    }
+    return m
+  }
+
+  //   (zipmap [:a :b] [1 2]) => {:a 1 :b 2}
+  function _zipmap(ks, vs) {
+    let m = EMPTY_MAP
+    const vals = [...seq(vs)]
+    const keys = [...seq(ks)].slice(0, vals.length)
+    keys.forEach((k, i) => {
+      m = m.assoc(k, vals[i])
+    })
+    return m
+  }
+
+  //   (into {} [[:a 1]]) => {:a 1}
+  //   (into [] {:a 1})   => [[:a 1]]
+  function _into(to, from) {
+    return _conj(to ?? [], ...seq(from))
+  }
+
+  // Partitions of n items, every step items,
+  // without the last one if it isn't full.
+  //
+  //   (partition 2 [1 2 3 4 5])   => [[1 2] [3 4]]
+  //   (partition 2 1 [1 2 3])     => [[1 2] [2 3]]
+  function _partition(n, ...args) {
+    const [step, coll] = args.length === 1 ? [n, args[0]] : args
+    if (!(n > 0) || !(step > 0)) {
+      throw new RangeError('partition size and step must be positive')
+    }
+    const xs = [...seq(coll)]
+    const result = []
+    for (let i = 0; i + n <= xs.length; i += step) {
+      result.push(xs.slice(i, i + n))
+    }
+    return result
+  }
+
+  // ==========================================================================
+  // Interop
+  // ==========================================================================
+
+  // Deeply converts to arrays and objects, where
+  // atoms and symbols become their names.
+  function _toJS(x) {
+    if (_isAtom(x)) {
+      return _atomName(x)
+    }
+    if (_isSymbol(x)) {
+      return _symbolName(x)
+    }
+    if (x instanceof Vector || x instanceof HashSet || Array.isArray(x)) {
+      return [...x].map(_toJS)
+    }
+    if (x instanceof HashMap || x instanceof Map) {
+      const obj = {}
+      for (const [k, v] of x) {
+        obj[_toJS(k)] = _toJS(v)
+      }
+      return obj
+    }
+    if (isObject(x)) {
+      const obj = {}
+      for (const k of Object.keys(x)) {
+        obj[k.startsWith(':') ? k.slice(1) : k] = _toJS(x[k])
+      }
+      return obj
+    }
+    return x
+  }
+
+  // Deeply converts arrays to vectors and objects
+  // to hash maps, with atom keys if keywordize.
+  function _fromJS(x, keywordize = false) {
+    if (Array.isArray(x)) {
+      return fromArray(x.map((y) => _fromJS(y, keywordize)))
+    }
+    if (isObject(x)) {
+      let m = EMPTY_MAP
+      for (const k of Object.keys(x)) {
+        m = m.assoc(keywordize ? _atom(k) : k, _fromJS(x[k], keywordize))
+      }
+      return m
+    }
+    return x
+  }
+
+  // A plain object, where atom keys become their
+  // names. Unlike _toJS, the values are kept.
+  //
+  //   (js-obj :a 1 "b" [2]) => { a: 1, b: [2] }
+  function _jsObj(...kvs) {
+    if (kvs.length % 2 !== 0) {
+      throw new TypeError(`expected value for key: ${kvs[kvs.length - 1]}`)
+    }
+    const obj = {}
+    for (let i = 0; i < kvs.length; i += 2) {
+      obj[_toJS(kvs[i])] = kvs[i + 1]
+    }
+    return obj
+  }
+
+  // Copies instead of reversing in place.
+  function _reverse(xs) {
+    const reversed = [...xs].reverse()
+    return xs instanceof Vector ? fromArray(reversed) : reversed
+  }
+
+  // Fisher-Yates, on a copy.
+  function _shuffle(xs) {
+    const shuffled = [...xs]
+    for (let i = shuffled.length - 1; i > 0; i--) {
+      const j = Math.floor(Math.random() * (i + 1))
+      ;[shuffled[i], shuffled[j]] = [shuffled[j], shuffled[i]]
+    }
+    return xs instanceof Vector ? fromArray(shuffled) : shuffled
+  }
+
+  return {
+    _vector,
+    _isVector,
+    _hashMap,
+    _isHashMap,
+    _hashSet,
+    _isHashSet,
+    _isObject: isObject,
+    _conj,
+    _assoc,
+    _dissoc,
+    _get,
+    _update,
+    _getIn,
+    _assocIn,
+    _reduce,
+    _keys,
+    _vals,
+    _merge,
+    _mergeWith,
+    _selectKeys,
+    _updateIn,
+    _groupBy,
+    _frequencies,
+    _zipmap,
+    _into,
+    _partition,
+    _toJS,
+    _fromJS,
+    _jsObj,
+    _reverse,
+    _shuffle,
+  }
+})()
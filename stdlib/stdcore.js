@@ -0,0 +1,588 @@
+// REMLISP STANDARD LIBRARY v0.1.0
+//
+// MIT License
+//
+// Copyright (c) 2024 Fredrik Holmqvist
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// ============================================================================
+// ATOMS
+// ============================================================================
+
+// Atoms are interned, so two atoms with the same
+// name are always identical (===). They are also
+// lookup functions, (:name user), and turn into
+// ":name" when used as object keys.
+//
+// The tag is a registered symbol so that atoms
+// can be recognized across realms (the REPL).
+// Collections that aren't objects, such as the
+// persistent ones, implement the lookup symbol.
+//
+// Every section is wrapped so that only the
+// underscored functions end up in scope.
+
+const { _atom, _isAtom, _atomName } = (() => {
+  const ATOM = Symbol.for('remlisp.atom')
+  const LOOKUP = Symbol.for('remlisp.lookup')
+
+  const atoms = new Map()
+
+  function show() {
+    return `:${this[ATOM]}`
+  }
+
+  // Console.log prints atoms as written, in Node
+  // and in Deno, instead of as functions.
+  const atomPrototype = Object.create(Function.prototype, {
+    toString: { value: show },
+    [Symbol.toPrimitive]: { value: show },
+    [Symbol.for('nodejs.util.inspect.custom')]: { value: show },
+    [Symbol.for('Deno.customInspect')]: { value: show },
+  })
+
+  function _atom(name) {
+    let atom = atoms.get(name)
+    if (atom) {
+      return atom
+    }
+    atom = (m, otherwise) => {
+      if (typeof m?.[LOOKUP] === 'function') {
+        return m[LOOKUP](atom, otherwise)
+      }
+      const v = m?.[atom]
+      return v === undefined ? otherwise : v
+    }
+    Object.setPrototypeOf(atom, atomPrototype)
+    Object.defineProperty(atom, 'name', { value: `:${name}` })
+    Object.defineProperty(atom, ATOM, { value: name })
+    atoms.set(name, Object.freeze(atom))
+    return atom
+  }
+
+  function _isAtom(x) {
+    return typeof x === 'function' && x[ATOM] !== undefined
+  }
+
+  function _atomName(x) {
+    return x[ATOM]
+  }
+
+  return { _atom, _isAtom, _atomName }
+})()
+
+// ============================================================================
+// SYMBOLS
+// ============================================================================
+
+// Symbols are what quoted identifiers become,
+// '(a b) => [a b]. Like atoms they are interned.
+
+const { _symbol, _isSymbol, _symbolName } = (() => {
+  const SYMBOL = Symbol.for('remlisp.symbol')
+
+  const symbols = new Map()
+
+  function show() {
+    return this[SYMBOL]
+  }
+
+  // Console.log prints symbols as written, in Node
+  // and in Deno, instead of as empty objects.
+  const symbolPrototype = {
+    toString: show,
+    [Symbol.for('nodejs.util.inspect.custom')]: show,
+    [Symbol.for('Deno.customInspect')]: show,
+  }
+
+  function _symbol(name) {
+    let symbol = symbols.get(name)
+    if (symbol) {
+      return symbol
+    }
+    symbol = Object.create(symbolPrototype, { [SYMBOL]: { value: name } })
+    symbols.set(name, Object.freeze(symbol))
+    return symbol
+  }
+
+  function _isSymbol(x) {
+    return x != null && typeof x === 'object' && x[SYMBOL] !== undefined
+  }
+
+  function _symbolName(x) {
+    return x[SYMBOL]
+  }
+
+  return { _symbol, _isSymbol, _symbolName }
+})()
+
+// ============================================================================
+// ITERATORS
+// ============================================================================
+
+// Lazy sequences are JS iterators, so they work
+// with anything iterable (arrays, strings, maps,
+// sets, generators) and only do work on demand.
+//
+//   _realize(_take(3, _iterate((x) => x * 2, 1))) => [1, 2, 4]
+
+const {
+  _iter,
+  _isIterable,
+  _lazyMap,
+  _lazyFilter,
+  _take,
+  _iterate,
+  _repeat,
+  _cycle,
+  _realize,
+} = (() => {
+  // Plain objects iterate as [key value] pairs,
+  // and nil as nothing.
+  function iterable(xs) {
+    if (xs == null) {
+      return []
+    }
+    if (_isIterable(xs)) {
+      return xs
+    }
+    if (typeof xs.next === 'function') {
+      return { [Symbol.iterator]: () => xs }
+    }
+    if (typeof xs === 'object') {
+      return Object.entries(xs)
+    }
+    throw new TypeError(`${xs} is not iterable`)
+  }
+
+  function _iter(xs) {
+    return iterable(xs)[Symbol.iterator]()
+  }
+
+  function _isIterable(xs) {
+    return xs != null && typeof xs[Symbol.iterator] === 'function'
+  }
+
+  function* _lazyMap(f, xs) {
+    for (const x of iterable(xs)) {
+      yield f(x)
+    }
+  }
+
+  function* _lazyFilter(f, xs) {
+    for (const x of iterable(xs)) {
+      if (_truthy(f(x))) {
+        yield x
+      }
+    }
+  }
+
+  function* _take(n, xs) {
+    if (n <= 0) {
+      return
+    }
+    let i = 0
+    for (const x of iterable(xs)) {
+      yield x
+      if (++i >= n) {
+        return
+      }
+    }
+  }
+
+  function* _iterate(f, x) {
+    for (;;) {
+      yield x
+      x = f(x)
+    }
+  }
+
+  function* _repeat(x) {
+    for (;;) {
+      yield x
+    }
+  }
+
+  // Remembers the first pass, so that
+  // generators can be cycled too.
+  function* _cycle(xs) {
+    const seen = []
+    for (const x of iterable(xs)) {
+      seen.push(x)
+      yield x
+    }
+    while (seen.length > 0) {
+      yield* seen
+    }
+  }
+
+  function _realize(xs) {
+    return Array.from(iterable(xs))
+  }
+
+  return {
+    _iter,
+    _isIterable,
+    _lazyMap,
+    _lazyFilter,
+    _take,
+    _iterate,
+    _repeat,
+    _cycle,
+    _realize,
+  }
+})()
+
+// ============================================================================
+// FUNCTIONS
+// ============================================================================
+
+// Helpers for the code that functions compile to.
+
+const { _arityError, _kwargs } = (() => {
+  // Thrown by multi-arity functions.
+  function _arityError(name, n, expected) {
+    return new TypeError(
+      `${name}: wrong number of arguments (${n}), expected ${expected}`,
+    )
+  }
+
+  // Keyword options, [x & {:keys [verbose]}], are
+  // either passed as a map or as keys and values.
+  //
+  //   (f 1 {:verbose true})
+  //   (f 1 :verbose true)
+  function _kwargs(opts) {
+    if (opts.length === 1 && opts[0] !== null && typeof opts[0] === 'object') {
+      return opts[0]
+    }
+    if (opts.length % 2 !== 0) {
+      throw new TypeError(`expected value for key: ${opts[opts.length - 1]}`)
+    }
+    const m = {}
+    for (let i = 0; i < opts.length; i += 2) {
+      m[opts[i]] = opts[i + 1]
+    }
+    return m
+  }
+
+  return { _arityError, _kwargs }
+})()
+
+// ============================================================================
+// TRUTHINESS
+// ============================================================================
+
+// Only nil and false are falsy, like in Clojure,
+// so 0, "" and NaN are truthy.
+
+const { _truthy } = (() => {
+  function _truthy(x) {
+    return x != null && x !== false
+  }
+
+  return { _truthy }
+})()
+
+// ============================================================================
+// EQUALITY
+// ============================================================================
+
+// Structural equality and hashing over vectors,
+// maps, sets and objects. Atoms and symbols are
+// interned, so they are compared by identity.
+// Other types can implement the equals and hash
+// symbols, like the persistent collections.
+
+const { _equals, _hash } = (() => {
+  const EQUALS = Symbol.for('remlisp.equals')
+  const HASH = Symbol.for('remlisp.hash')
+
+  function isObject(x) {
+    if (x === null || typeof x !== 'object') {
+      return false
+    }
+    const proto = Object.getPrototypeOf(x)
+    return proto === null || proto === Object.prototype
+  }
+
+  function _equals(a, b) {
+    if (a === b || (a == null && b == null)) {
+      return true
+    }
+    if (typeof a?.[EQUALS] === 'function') {
+      return a[EQUALS](b)
+    }
+    if (typeof b?.[EQUALS] === 'function') {
+      return b[EQUALS](a)
+    }
+    if (Array.isArray(a)) {
+      return (
+        Array.isArray(b) &&
+        a.length === b.length &&
+        a.every((x, i) => _equals(x, b[i]))
+      )
+    }
+    if (a instanceof Map) {
+      if (!(b instanceof Map) || a.size !== b.size) {
+        return false
+      }
+      for (const [k, v] of a) {
+        if (!b.has(k) || !_equals(v, b.get(k))) {
+          return false
+        }
+      }
+      return true
+    }
+    if (a instanceof Set) {
+      if (!(b instanceof Set) || a.size !== b.size) {
+        return false
+      }
+      for (const x of a) {
+        if (!b.has(x) && ![...b].some((y) => _equals(x, y))) {
+          return false
+        }
+      }
+      return true
+    }
+    if (isObject(a)) {
+      if (!isObject(b)) {
+        return false
+      }
+      const keys = Object.keys(a)
+      if (keys.length !== Object.keys(b).length) {
+        return false
+      }
+      return keys.every((k) => Object.hasOwn(b, k) && _equals(a[k], b[k]))
+    }
+    return false
+  }
+
+  function hashString(s) {
+    let h = 0
+    for (let i = 0; i < s.length; i++) {
+      h = (Math.imul(31, h) + s.charCodeAt(i)) | 0
+    }
+    return h
+  }
+
+  // Keys of Maps and objects, where objects have
+  // strings, and ":name" for atoms, as keys.
+  function keyHash(k) {
+    if (typeof k === 'string' || _isAtom(k)) {
+      return hashString(String(k))
+    }
+    return _hash(k)
+  }
+
+  // Values that are equal have the same hash. Maps,
+  // sets and objects don't depend on key order.
+  function _hash(x) {
+    if (x == null) {
+      return 0
+    }
+    if (typeof x[HASH] === 'function') {
+      return x[HASH]()
+    }
+    if (Array.isArray(x)) {
+      return x.reduce((h, y) => (Math.imul(31, h) + _hash(y)) | 0, 1)
+    }
+    if (x instanceof Map) {
+      let h = 0
+      for (const [k, v] of x) {
+        h = (h + (keyHash(k) ^ _hash(v))) | 0
+      }
+      return h
+    }
+    if (x instanceof Set) {
+      let h = 0
+      for (const y of x) {
+        h = (h + _hash(y)) | 0
+      }
+      return h
+    }
+    if (isObject(x)) {
+      let h = 0
+      for (const k of Object.keys(x)) {
+        h = (h + (keyHash(k) ^ _hash(x[k]))) | 0
+      }
+      return h
+    }
+    return hashString(`${typeof x}:${String(x)}`)
+  }
+
+  return { _equals, _hash }
+})()
+
+// ============================================================================
+// OPERATORS
+// ============================================================================
+
+// Operators used as values, (reduce + 0 xs), with
+// the same semantics as when they are called.
+
+const {
+  _add,
+  _sub,
+  _mul,
+  _div,
+  _mod,
+  _eq,
+  _neq,
+  _lt,
+  _lte,
+  _gt,
+  _gte,
+  _and,
+  _or,
+  _pow,
+  _quot,
+  _rem,
+  _bitAnd,
+  _bitOr,
+  _bitXor,
+  _bitNot,
+  _bitShiftLeft,
+  _bitShiftRight,
+  _unsignedBitShiftRight,
+} = (() => {
+  function atLeastOne(name, xs) {
+    if (xs.length === 0) {
+      throw _arityError(name, 0, 'at least 1')
+    }
+  }
+
+  function exactly(name, xs, n) {
+    if (xs.length !== n) {
+      throw _arityError(name, xs.length, `${n}`)
+    }
+  }
+
+  // Comparisons are chained, (< a b c).
+  function chain(name, cmp) {
+    return (...xs) => {
+      atLeastOne(name, xs)
+      for (let i = 1; i < xs.length; i++) {
+        if (!cmp(xs[i - 1], xs[i])) {
+          return false
+        }
+      }
+      return true
+    }
+  }
+
+  function fold(name, op) {
+    return (...xs) => {
+      atLeastOne(name, xs)
+      return xs.reduce(op)
+    }
+  }
+
+  function _add(...xs) {
+    return xs.reduce((a, b) => a + b, 0)
+  }
+
+  function _sub(...xs) {
+    atLeastOne('-', xs)
+    return xs.length === 1 ? -xs[0] : xs.reduce((a, b) => a - b)
+  }
+
+  function _mul(...xs) {
+    return xs.reduce((a, b) => a * b, 1)
+  }
+
+  function _div(...xs) {
+    atLeastOne('/', xs)
+    return xs.length === 1 ? 1 / xs[0] : xs.reduce((a, b) => a / b)
+  }
+
+  const _eq = chain('=', _equals)
+
+  function _neq(...xs) {
+    atLeastOne('!=', xs)
+    return !_eq(...xs)
+  }
+
+  function _and(...xs) {
+    let x = true
+    for (x of xs) {
+      if (!_truthy(x)) {
+        return x
+      }
+    }
+    return x
+  }
+
+  function _or(...xs) {
+    let x = null
+    for (x of xs) {
+      if (_truthy(x)) {
+        return x
+      }
+    }
+    return x
+  }
+
+  function _pow(...xs) {
+    atLeastOne('**', xs)
+    return xs.reduceRight((a, b) => b ** a)
+  }
+
+  function _quot(...xs) {
+    exactly('quot', xs, 2)
+    return Math.trunc(xs[0] / xs[1])
+  }
+
+  function _rem(...xs) {
+    exactly('rem', xs, 2)
+    return xs[0] % xs[1]
+  }
+
+  function _bitNot(...xs) {
+    exactly('bit-not', xs, 1)
+    return ~xs[0]
+  }
+
+  return {
+    _add,
+    _sub,
+    _mul,
+    _div,
+    _mod: fold('%', (a, b) => a % b),
+    _eq,
+    _neq,
+    _lt: chain('<', (a, b) => a < b),
+    _lte: chain('<=', (a, b) => a <= b),
+    _gt: chain('>', (a, b) => a > b),
+    _gte: chain('>=', (a, b) => a >= b),
+    _and,
+    _or,
+    _pow,
+    _quot,
+    _rem,
+    _bitAnd: fold('bit-and', (a, b) => a & b),
+    _bitOr: fold('bit-or', (a, b) => a | b),
+    _bitXor: fold('bit-xor', (a, b) => a ^ b),
+    _bitNot,
+    _bitShiftLeft: fold('bit-shift-left', (a, b) => a << b),
+    _bitShiftRight: fold('bit-shift-right', (a, b) => a >> b),
+    _unsignedBitShiftRight: fold('unsigned-bit-shift-right', (a, b) => a >>> b),
+  }
+})()
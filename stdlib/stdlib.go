@@ -2,8 +2,22 @@ package stdlib
 
 import (
 	_ "embed"
+	"slices"
 )
 
+//go:embed stdcore.js
+var StdCore []byte
+
+//go:embed stdcoll.js
+var StdColl []byte
+
+//go:embed stdreader.js
+var StdReader []byte
+
+// The JavaScript parts of the standard library,
+// which everything else depends on.
+var StdJS = slices.Concat(StdCore, []byte("\n"), StdColl, []byte("\n"), StdReader)
+
 //go:embed stdfns.rem
 var StdFns []byte
 
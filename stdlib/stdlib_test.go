@@ -0,0 +1,99 @@
+package stdlib_test
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/fholmqvist/remlisp/compiler"
+	"github.com/fholmqvist/remlisp/expander"
+	h "github.com/fholmqvist/remlisp/highlight"
+	"github.com/fholmqvist/remlisp/lexer"
+	"github.com/fholmqvist/remlisp/parser"
+	"github.com/fholmqvist/remlisp/pp"
+	"github.com/fholmqvist/remlisp/runtime"
+	"github.com/fholmqvist/remlisp/stdlib"
+	"github.com/fholmqvist/remlisp/transpiler"
+)
+
+// A runtime for the test, which is stopped when
+// the test is done. Tests are skipped without Deno.
+func newRuntime(t *testing.T) *runtime.Runtime {
+	t.Helper()
+	if _, err := exec.LookPath("deno"); err != nil {
+		t.Skip("deno is not installed")
+	}
+	rt, erre := runtime.New()
+	if erre != nil {
+		t.Fatal(erre)
+	}
+	t.Cleanup(rt.Close)
+	return rt
+}
+
+// Evaluates the JS inputs of tests in a runtime,
+// expecting their outputs.
+func expectJS(t *testing.T, tests []struct{ input, output string }) {
+	rt := newRuntime(t)
+	expect(t, rt, tests, func(t *testing.T, input string) string {
+		return input
+	})
+}
+
+// Evaluates the remlisp inputs of tests in a
+// runtime with the stdlib, expecting their outputs.
+func expectRem(t *testing.T, tests []struct{ input, output string }) {
+	expectRemWith(t, transpiler.Options{}, tests)
+}
+
+// Like expectRem, but compiled with opts.
+func expectRemWith(t *testing.T, opts transpiler.Options, tests []struct{ input, output string }) {
+	rt := newRuntime(t)
+	expect(t, rt, tests, compilerFor(t, rt, opts))
+}
+
+func expect(t *testing.T, rt *runtime.Runtime, tests []struct{ input, output string }, compile func(*testing.T, string) string) {
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := send(t, rt, compile(t, tt.input))
+			if got != tt.output {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n",
+					h.Code(tt.output), h.Code(got))
+			}
+		})
+	}
+}
+
+// Loads the stdlib into rt, returning a function
+// that compiles remlisp with its macros.
+func compilerFor(t *testing.T, rt *runtime.Runtime, opts transpiler.Options) func(*testing.T, string) string {
+	lex := lexer.New()
+	prs, trn := parser.New(lex), transpiler.NewWithOptions(opts)
+	exp := expander.New(lex, prs, trn, rt)
+	cmp := compiler.New(lex, prs, trn)
+	compile := func(t *testing.T, input string) string {
+		js, erre := cmp.Compile([]byte(input), exp)
+		if erre != nil {
+			t.Fatalf("%s: %s", input, erre.Msg)
+		}
+		return js
+	}
+	for _, src := range [][]byte{stdlib.StdFns, stdlib.StdMacros} {
+		if _, erre := rt.Send(compile(t, string(src))); erre != nil {
+			t.Fatal(erre)
+		}
+	}
+	return compile
+}
+
+// Evaluates js in rt, printed as remlisp.
+func send(t *testing.T, rt *runtime.Runtime, js string) string {
+	out, erre := rt.Send(js)
+	if erre != nil {
+		t.Fatal(erre)
+	}
+	lisp, err := pp.ParseResponseRaw([]byte(js), out)
+	if err != nil {
+		t.Fatalf("%s: %s", js, err)
+	}
+	return lisp
+}
@@ -0,0 +1,275 @@
+// ============================================================================
+// READER
+// ============================================================================
+
+// A port of the Go lexer and parser that reads
+// remlisp source into the same data that quoted
+// forms produce.
+//
+//   _readString('(a :b [1 "c"])') => [a :b [1 "c"]]
+
+const { _readString, _readAll } = (() => {
+  class Reader {
+    constructor(input) {
+      this.input = input
+      this.i = 0
+    }
+
+    readAll() {
+      const forms = []
+      for (;;) {
+        this.skip()
+        if (!this.inRange()) {
+          return forms
+        }
+        const form = this.read()
+        if (form !== DISCARDED) {
+          forms.push(form)
+        }
+      }
+    }
+
+    read() {
+      this.skip()
+      if (!this.inRange()) {
+        this.error('unexpected end of input')
+      }
+      const ch = this.ch()
+      const next = this.peek()
+      switch (true) {
+        case isNumber(ch, next):
+          return this.readNumber()
+        case isIdent(ch):
+          return this.readIdent()
+        case ch === '"':
+          return this.readString()
+        case ch === ':':
+          this.i++
+          return _atom(this.readWord())
+        case ch === '#' && next === '_':
+          this.i += 2
+          this.readForm()
+          return DISCARDED
+        case isOperator(ch):
+          return this.readOperator()
+        case ch === '(':
+          this.i++
+          return this.readSeq(')')
+        case ch === '[':
+          this.i++
+          return this.readSeq(']')
+        case ch === '{':
+          this.i++
+          return this.readMap()
+        case ch === '&':
+          this.i++
+          return _symbol('&')
+        case ch === "'":
+          this.i++
+          return [_symbol('quote'), this.readForm()]
+        case ch === '`':
+          this.i++
+          return [_symbol('quasiquote'), this.readForm()]
+        case ch === ',':
+          this.i++
+          if (this.ch() === '@') {
+            this.i++
+            return [_symbol('unquote-splicing'), this.readForm()]
+          }
+          return [_symbol('unquote'), this.readForm()]
+        case ch === ')' || ch === ']' || ch === '}':
+          this.error(`unexpected token: "${ch}"`)
+          break
+        default:
+          this.error(`unexpected character: '${ch}'`)
+      }
+    }
+
+    // Like read, but skips over discarded forms.
+    readForm() {
+      for (;;) {
+        const form = this.read()
+        if (form !== DISCARDED) {
+          return form
+        }
+      }
+    }
+
+    readSeq(end) {
+      const seq = []
+      for (;;) {
+        this.skip()
+        if (!this.inRange()) {
+          this.error('unexpected end of input')
+        }
+        if (this.ch() === end) {
+          this.i++
+          return seq
+        }
+        const form = this.read()
+        if (form !== DISCARDED) {
+          seq.push(form)
+        }
+      }
+    }
+
+    readMap() {
+      const kvs = this.readSeq('}')
+      if (kvs.length % 2 !== 0) {
+        this.error('expected value for key')
+      }
+      const map = {}
+      for (let i = 0; i < kvs.length; i += 2) {
+        map[kvs[i]] = kvs[i + 1]
+      }
+      return map
+    }
+
+    readNumber() {
+      const start = this.i
+      const word = this.readWord()
+      const n = Number(word)
+      if (!/^-?[0-9.]+$/.test(word) || Number.isNaN(n)) {
+        this.error(`invalid number: "${word}"`, start)
+      }
+      return n
+    }
+
+    readIdent() {
+      const word = this.readWord()
+      switch (word) {
+        case 'true':
+          return true
+        case 'false':
+          return false
+        case 'nil':
+          return null
+        default:
+          return _symbol(word)
+      }
+    }
+
+    readString() {
+      const start = this.i
+      this.i++
+      const end = this.input.indexOf('"', this.i)
+      if (end < 0) {
+        this.error('unterminated string', start)
+      }
+      const s = this.input.slice(this.i, end)
+      this.i = end + 1
+      return s
+    }
+
+    readOperator() {
+      let op = this.ch()
+      this.i++
+      if (isComplexOperator(op, this.ch())) {
+        op += this.ch()
+        this.i++
+      }
+      return _symbol(op)
+    }
+
+    readWord() {
+      const start = this.i
+      while (this.inRange() && !isDelimiter(this.ch())) {
+        this.i++
+      }
+      return this.input.slice(start, this.i)
+    }
+
+    // Skips whitespace and comments.
+    skip() {
+      while (this.inRange()) {
+        const ch = this.ch()
+        if (ch === ' ' || ch === '\n' || ch === '\t' || ch === '\r') {
+          this.i++
+        } else if (ch === ';') {
+          while (this.inRange() && this.ch() !== '\n') {
+            this.i++
+          }
+        } else if (ch === '#' && this.peek() === '|') {
+          this.skipBlockComment()
+        } else {
+          return
+        }
+      }
+    }
+
+    skipBlockComment() {
+      const start = this.i
+      let depth = 0
+      while (this.inRange()) {
+        if (this.ch() === '#' && this.peek() === '|') {
+          depth++
+          this.i++
+        } else if (this.ch() === '|' && this.peek() === '#') {
+          depth--
+          this.i++
+        }
+        this.i++
+        if (depth === 0) {
+          return
+        }
+      }
+      this.error('unterminated block comment', start)
+    }
+
+    ch() {
+      return this.input[this.i]
+    }
+
+    peek() {
+      return this.input[this.i + 1]
+    }
+
+    inRange() {
+      return this.i < this.input.length
+    }
+
+    error(msg, at = this.i) {
+      throw new Error(`[byte index ${at + 1}] ${msg}`)
+    }
+  }
+
+  const DISCARDED = Symbol('discarded')
+
+  function isNumber(ch, next) {
+    return isDigit(ch) || (ch === '-' && isDigit(next))
+  }
+
+  function isDigit(ch) {
+    return ch >= '0' && ch <= '9'
+  }
+
+  function isIdent(ch) {
+    return (
+      !isDelimiter(ch) &&
+      (/[a-zA-Z_.\->?!]/.test(ch) || ch.charCodeAt(0) > 127)
+    )
+  }
+
+  function isOperator(ch) {
+    return '+-*/%=<>!'.includes(ch)
+  }
+
+  function isComplexOperator(a, b) {
+    return (a === '!' || a === '<' || a === '>') && b === '='
+  }
+
+  function isDelimiter(ch) {
+    return ' ,:\n\t\r[](){}'.includes(ch)
+  }
+
+  function _readString(s) {
+    const forms = new Reader(s).readAll()
+    return forms.length > 0 ? forms[0] : null
+  }
+
+  function _readAll(s) {
+    return new Reader(s).readAll()
+  }
+
+  return { _readString, _readAll }
+})()
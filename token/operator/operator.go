@@ -23,6 +23,16 @@ const (
 	GTE
 	AND
 	OR
+	POW
+	QUOT
+	REM
+	BIT_AND
+	BIT_OR
+	BIT_XOR
+	BIT_NOT
+	BIT_SHIFT_LEFT
+	BIT_SHIFT_RIGHT
+	UNSIGNED_BIT_SHIFT_RIGHT
 )
 
 func From(s string) (Operator, error) {
@@ -53,11 +63,72 @@ func From(s string) (Operator, error) {
 		return AND, nil
 	case "or":
 		return OR, nil
+	case "**":
+		return POW, nil
+	case "quot":
+		return QUOT, nil
+	case "rem":
+		return REM, nil
+	case "bit-and":
+		return BIT_AND, nil
+	case "bit-or":
+		return BIT_OR, nil
+	case "bit-xor":
+		return BIT_XOR, nil
+	case "bit-not":
+		return BIT_NOT, nil
+	case "bit-shift-left":
+		return BIT_SHIFT_LEFT, nil
+	case "bit-shift-right":
+		return BIT_SHIFT_RIGHT, nil
+	case "unsigned-bit-shift-right":
+		return UNSIGNED_BIT_SHIFT_RIGHT, nil
 	default:
 		return UNKNOWN, fmt.Errorf("unknown operator: %s", s)
 	}
 }
 
+// The JS operator, for operators that have one.
+func (o Operator) JS() string {
+	switch o {
+	case EQ:
+		return "==="
+	case NEQ:
+		return "!=="
+	case AND:
+		return "&&"
+	case OR:
+		return "||"
+	case REM:
+		return "%"
+	case BIT_AND:
+		return "&"
+	case BIT_OR:
+		return "|"
+	case BIT_XOR:
+		return "^"
+	case BIT_NOT:
+		return "~"
+	case BIT_SHIFT_LEFT:
+		return "<<"
+	case BIT_SHIFT_RIGHT:
+		return ">>"
+	case UNSIGNED_BIT_SHIFT_RIGHT:
+		return ">>>"
+	default:
+		return o.String()
+	}
+}
+
+func (o Operator) IsComparison() bool {
+	switch o {
+	case EQ, NEQ, LT, LTE, GT, GTE:
+		return true
+	default:
+		return false
+	}
+}
+
 func (o Operator) String() string {
 	switch o {
 	case UNKNOWN:
@@ -88,6 +159,26 @@ func (o Operator) String() string {
 		return "and"
 	case OR:
 		return "or"
+	case POW:
+		return "**"
+	case QUOT:
+		return "quot"
+	case REM:
+		return "rem"
+	case BIT_AND:
+		return "bit-and"
+	case BIT_OR:
+		return "bit-or"
+	case BIT_XOR:
+		return "bit-xor"
+	case BIT_NOT:
+		return "bit-not"
+	case BIT_SHIFT_LEFT:
+		return "bit-shift-left"
+	case BIT_SHIFT_RIGHT:
+		return "bit-shift-right"
+	case UNSIGNED_BIT_SHIFT_RIGHT:
+		return "unsigned-bit-shift-right"
 	default:
 		e.Panic("unknown operator", fmt.Sprintf("%d", o))
 		return ""
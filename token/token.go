@@ -294,3 +294,34 @@ func (c AtSign) String() string {
 func (c AtSign) Pos() Position {
 	return c.P
 }
+
+type Discard struct {
+	P Position
+}
+
+func (Discard) Token() {}
+
+func (c Discard) String() string {
+	return "#_"
+}
+
+func (c Discard) Pos() Position {
+	return c.P
+}
+
+// The opening of an anonymous function shorthand.
+//
+//	#(+ % 1)
+type FnShorthand struct {
+	P Position
+}
+
+func (FnShorthand) Token() {}
+
+func (f FnShorthand) String() string {
+	return "#("
+}
+
+func (f FnShorthand) Pos() Position {
+	return f.P
+}
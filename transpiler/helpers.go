@@ -2,8 +2,14 @@ package transpiler
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 
+	ex "github.com/fholmqvist/remlisp/expr"
+	"github.com/fholmqvist/remlisp/token/operator"
+	"github.com/fholmqvist/remlisp/transpiler/js"
+	"github.com/fholmqvist/remlisp/transpiler/mangle"
 	"github.com/fholmqvist/remlisp/transpiler/state"
 )
 
@@ -33,11 +39,418 @@ func (t *Transpiler) restoreState() {
 	}
 }
 
+func (t *Transpiler) setFnState(async, generator bool) {
+	if async {
+		t.setState(state.IN_ASYNC_FN)
+	} else if generator {
+		t.setState(state.IN_GEN_FN)
+	} else {
+		t.setState(state.IN_FN)
+	}
+}
+
+// The state of the innermost function, or
+// UNKNOWN at the top level.
+func (t *Transpiler) fnState() state.State {
+	for i := len(t.state) - 1; i >= 0; i-- {
+		switch t.state[i] {
+		case state.IN_FN, state.IN_ASYNC_FN, state.IN_GEN_FN:
+			return t.state[i]
+		}
+	}
+	return state.UNKNOWN
+}
+
+// Whether the innermost function is synchronous.
+// The top level is async (modules and the REPL).
+func (t *Transpiler) inSyncFn() bool {
+	s := t.fnState()
+	return s == state.IN_FN || s == state.IN_GEN_FN
+}
+
+// Wraps statements in an immediately invoked
+// function, which is async and awaited if expr
+// awaits, or a generator that is delegated to if
+// expr yields.
+//
+//	(() => { ... })()
+//	await (async () => { ... })()
+//	yield* (function* () { ... })()
+func (t *Transpiler) iife(expr ex.Expr, body []js.Stmt) js.Expr {
+	if contains(expr, "yield") {
+		fn := &js.Func{Generator: true, Body: body}
+		return &js.Yield{Arg: &js.Call{Callee: fn}, Delegate: true}
+	} else if contains(expr, "await") {
+		fn := &js.Func{Arrow: true, Async: true, Body: body}
+		return &js.Await{Arg: &js.Call{Callee: fn}}
+	}
+	return &js.Call{Callee: &js.Func{Arrow: true, Body: body}}
+}
+
+// Whether expr contains a call to head, without
+// looking inside of nested functions.
+func contains(expr ex.Expr, head string) bool {
+	switch expr := expr.(type) {
+	case *ex.List:
+		if len(expr.V) > 0 && expr.V[0].String() == head {
+			return true
+		}
+		for _, e := range expr.V {
+			if contains(e, head) {
+				return true
+			}
+		}
+	case *ex.Vec:
+		for _, e := range expr.V {
+			if contains(e, head) {
+				return true
+			}
+		}
+	case *ex.Map:
+		for _, e := range expr.V {
+			if contains(e, head) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Whether a function refers to this, without
+// looking inside of nested functions.
+func usesThis(fn *ex.AnonymousFn) bool {
+	var uses func(expr ex.Expr) bool
+	uses = func(expr ex.Expr) bool {
+		switch expr := expr.(type) {
+		case ex.Identifier:
+			return expr.V == "this" || strings.HasPrefix(expr.V, "this.")
+		case *ex.List:
+			return slices.ContainsFunc(expr.V, uses)
+		case *ex.Vec:
+			return slices.ContainsFunc(expr.V, uses)
+		case *ex.Map:
+			return slices.ContainsFunc(expr.V, uses)
+		}
+		return false
+	}
+	if len(fn.Arities) > 0 {
+		return slices.ContainsFunc(fn.Arities, func(a ex.Arity) bool {
+			return uses(a.Params) || uses(a.Body)
+		})
+	}
+	return uses(fn.Params) || uses(fn.Body)
+}
+
+// Whether expr recurs to the innermost function,
+// so recur inside of nested loops doesn't count.
+func recurs(expr ex.Expr) bool {
+	switch expr := expr.(type) {
+	case *ex.List:
+		if len(expr.V) > 0 {
+			switch expr.V[0].String() {
+			case "recur":
+				return true
+			case "loop":
+				return false
+			}
+		}
+		for _, e := range expr.V {
+			if recurs(e) {
+				return true
+			}
+		}
+	case *ex.Vec:
+		for _, e := range expr.V {
+			if recurs(e) {
+				return true
+			}
+		}
+	case *ex.Map:
+		for _, e := range expr.V {
+			if recurs(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// When and unless are sugar for if, with an implicit do.
+//
+//	(when c a b)   => (if c (do a b) nil)
+//	(unless c a b) => (if c nil (do a b))
+func desugarWhen(list *ex.List) *ex.List {
+	body := list.V[2]
+	if len(list.V) > 3 {
+		do := ex.Identifier{V: "do", P: list.V[0].Pos()}
+		body = &ex.List{V: append([]ex.Expr{do}, list.V[2:]...), P: list.P}
+	}
+	iff := ex.Identifier{V: "if", P: list.V[0].Pos()}
+	none := ex.Nil{P: list.P}
+	if list.V[0].String() == "unless" {
+		return &ex.List{V: []ex.Expr{iff, list.V[1], none, body}, P: list.P}
+	}
+	return &ex.List{V: []ex.Expr{iff, list.V[1], body, none}, P: list.P}
+}
+
+// Cond as nested ifs.
+//
+//	(cond a 1 b 2 :else 3) => (if a 1 (if b 2 3))
+func desugarCond(list *ex.List) ex.Expr {
+	var expr ex.Expr = ex.Nil{P: list.P}
+	clauses := list.V[1:]
+	for i := len(clauses) - 2; i >= 0; i -= 2 {
+		if isElse(clauses[i]) {
+			expr = clauses[i+1]
+			continue
+		}
+		iff := ex.Identifier{V: "if", P: list.V[0].Pos()}
+		expr = &ex.List{V: []ex.Expr{iff, clauses[i], clauses[i+1], expr}, P: list.P}
+	}
+	return expr
+}
+
+func isElse(expr ex.Expr) bool {
+	atom, ok := expr.(ex.Atom)
+	return ok && atom.V == "else"
+}
+
+// Whether expr is statically boolean, so that
+// it doesn't need a truthiness check.
+func isBoolean(expr ex.Expr) bool {
+	switch expr := expr.(type) {
+	case ex.Bool:
+		return true
+	case *ex.List:
+		if len(expr.V) == 0 {
+			return false
+		}
+		op, err := operator.From(expr.V[0].String())
+		if err != nil {
+			return false
+		}
+		switch op {
+		case operator.EQ, operator.NEQ, operator.LT, operator.LTE, operator.GT, operator.GTE:
+			return true
+		case operator.AND, operator.OR:
+			for _, e := range expr.V[1:] {
+				if !isBoolean(e) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Whether every expr can be evaluated more
+// than once without side effects.
+func allSimple(exprs []ex.Expr) bool {
+	for _, expr := range exprs {
+		switch expr.(type) {
+		case ex.Nil, ex.Int, ex.Float, ex.Bool, ex.String, ex.Atom, ex.Identifier:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Whether expr is statically a primitive, or an
+// interned atom, so that === is equality.
+func isPrimitive(expr ex.Expr) bool {
+	switch expr := expr.(type) {
+	case ex.Int, ex.Float, ex.Bool, ex.String, ex.Atom:
+		return true
+	case *ex.List:
+		if len(expr.V) == 0 {
+			return false
+		}
+		op, ok := expr.V[0].(ex.Op)
+		return ok && op.Op != operator.AND && op.Op != operator.OR
+	}
+	return false
+}
+
+// Whether expr can be the head of a call,
+// as opposed to the head of a data list.
+//
+//	((f) 1) => f()(1)
+//	(1 2)   => [1, 2]
+func isCallable(expr ex.Expr) bool {
+	switch expr := expr.(type) {
+	case *ex.AnonymousFn:
+		return true
+	case *ex.List:
+		return len(expr.V) > 0
+	}
+	return false
+}
+
+// Whether the head of a list is an interop form,
+// a property, a method or a constructor.
+//
+//	.-length, .push, Map.
+func isInterop(head string) bool {
+	if len(head) < 2 || head == ".." {
+		return false
+	}
+	return strings.HasPrefix(head, ".") || strings.HasSuffix(head, ".")
+}
+
+// Whether expr is a statement in JS, without
+// a value.
+//
+//	(while c ...), (var x 1), (js* "debugger")
+func isStatement(expr ex.Expr) bool {
+	list, ok := expr.(*ex.List)
+	if !ok || len(list.V) == 0 {
+		return false
+	}
+	switch list.V[0].String() {
+	case "while", "var", "throw":
+		return true
+	case "js*":
+		code, ok := list.V[1].(ex.String)
+		return ok && isJSStatement(strings.TrimSpace(code.V))
+	}
+	return false
+}
+
+// Whether expr is the same value wherever it is
+// evaluated, so that it doesn't need a temporary.
+func isConstant(expr ex.Expr) bool {
+	switch expr.(type) {
+	case ex.Nil, ex.Int, ex.Float, ex.Bool, ex.String, ex.Atom, ex.Op,
+		*ex.AnonymousFn, *ex.Quote:
+		return true
+	}
+	return false
+}
+
+// The value of a function body that only
+// returns it.
+//
+//	return x; => x
+func returnsOnly(body []js.Stmt) (js.Expr, bool) {
+	if len(body) != 1 {
+		return nil, false
+	}
+	ret, ok := body[0].(*js.Return)
+	if !ok || ret.Arg == nil {
+		return nil, false
+	}
+	return ret.Arg, true
+}
+
+// Statements that can't be used as expressions,
+// a keyword or a label first, or a semicolon last.
+var jsStatement = regexp.MustCompile(
+	`^((if|for|while|do|switch|try|throw|return|break|continue|let|const|var|debugger)\b|[A-Za-z_$][\w$]*\s*:[^:])|;$`)
+
+// Whether a raw JS fragment is a statement.
+//
+//	"x instanceof Map" => false
+//	"debugger"         => true
+func isJSStatement(code string) bool {
+	return jsStatement.MatchString(code)
+}
+
+// Joins the last two with "or".
+//
+//	["1", "2", "3"] => "1, 2 or 3"
+func joinOr(ss []string) string {
+	if len(ss) < 2 {
+		return strings.Join(ss, "")
+	}
+	return fmt.Sprintf("%s or %s", strings.Join(ss[:len(ss)-1], ", "), ss[len(ss)-1])
+}
+
+// The stdlib functions that operators
+// become when used as values.
+//
+//	(reduce + 0 xs) => reduce(_add, 0, xs)
+var operatorFns = map[operator.Operator]string{
+	operator.ADD:                      "_add",
+	operator.SUB:                      "_sub",
+	operator.MUL:                      "_mul",
+	operator.DIV:                      "_div",
+	operator.MOD:                      "_mod",
+	operator.EQ:                       "_eq",
+	operator.NEQ:                      "_neq",
+	operator.LT:                       "_lt",
+	operator.LTE:                      "_lte",
+	operator.GT:                       "_gt",
+	operator.GTE:                      "_gte",
+	operator.AND:                      "_and",
+	operator.OR:                       "_or",
+	operator.POW:                      "_pow",
+	operator.QUOT:                     "_quot",
+	operator.REM:                      "_rem",
+	operator.BIT_AND:                  "_bitAnd",
+	operator.BIT_OR:                   "_bitOr",
+	operator.BIT_XOR:                  "_bitXor",
+	operator.BIT_NOT:                  "_bitNot",
+	operator.BIT_SHIFT_LEFT:           "_bitShiftLeft",
+	operator.BIT_SHIFT_RIGHT:          "_bitShiftRight",
+	operator.UNSIGNED_BIT_SHIFT_RIGHT: "_unsignedBitShiftRight",
+}
+
+// Whether literals are persistent collections,
+// which macro templates never are.
+func (t *Transpiler) persistent() bool {
+	return t.opts.Persistent && !t.hasState(state.IN_QUASI)
+}
+
+func (t *Transpiler) nilValue() js.Expr {
+	if t.opts.NilUndefined {
+		return &js.Ident{Name: "undefined"}
+	}
+	return &js.Lit{Raw: "null"}
+}
+
+// Whether code is the nil that nilValue returns.
+func (t *Transpiler) isNil(code js.Expr) bool {
+	switch code := code.(type) {
+	case *js.Lit:
+		return code.Raw == "null"
+	case *js.Ident:
+		return t.opts.NilUndefined && code.Name == "undefined"
+	}
+	return false
+}
+
+// A call to a function by name.
+//
+//	_atom("a")
+func call(fn string, args ...js.Expr) *js.Call {
+	return &js.Call{Callee: &js.Ident{Name: fn}, Args: args}
+}
+
+func truthy(code js.Expr) js.Expr {
+	return call("_truthy", code)
+}
+
+// Joins operands with a left associative operator.
+//
+//	[a, b, c] => a + b + c
+func chain(op string, codes []js.Expr) js.Expr {
+	code := codes[0]
+	for _, c := range codes[1:] {
+		code = &js.Binary{Op: op, Left: code, Right: c}
+	}
+	return code
+}
+
+// The JS name for a remlisp name. Names in the
+// js/ namespace are JS names already.
+//
+//	empty?      => empty$QMARK$
+//	js/document => document
 func fixName(s string) string {
-	s = strings.ReplaceAll(s, "->>", "_darrow_")
-	s = strings.ReplaceAll(s, "->", "_arrow_")
-	s = strings.ReplaceAll(s, "-", "_")
-	s = strings.ReplaceAll(s, "?", "P")
-	s = strings.ReplaceAll(s, "!", "Ex")
-	return s
+	if name, ok := strings.CutPrefix(s, "js/"); ok {
+		return name
+	}
+	return mangle.Name(s)
 }
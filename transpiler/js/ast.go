@@ -0,0 +1,309 @@
+package js
+
+import "strconv"
+
+// JS syntax trees, in the shape of ESTree,
+// which the transpiler builds and Print prints.
+type Node interface {
+	node()
+}
+
+type Expr interface {
+	Node
+	expr()
+}
+
+type Stmt interface {
+	Node
+	stmt()
+}
+
+// Expressions.
+
+// A name, which may be a dotted path.
+//
+//	x, Math.trunc
+type Ident struct {
+	Name string
+}
+
+// A number, a boolean or null, as it's written.
+type Lit struct {
+	Raw string
+}
+
+// A string, where V is the content between the
+// quotes, with its escapes.
+type String struct {
+	V string
+}
+
+type Array struct {
+	Elems []Expr
+}
+
+// An object literal, or an object pattern
+// when it's bound to.
+type Object struct {
+	Props []Prop
+}
+
+// A property of an object, where a computed
+// key is written [key].
+type Prop struct {
+	Key      Expr
+	Computed bool
+	Value    Expr
+}
+
+type Spread struct {
+	Arg Expr
+}
+
+type Call struct {
+	Callee Expr
+	Args   []Expr
+}
+
+type New struct {
+	Callee Expr
+	Args   []Expr
+}
+
+// A property access, obj.prop.
+type Member struct {
+	Obj  Expr
+	Prop string
+}
+
+// A computed property access, obj[index].
+type Index struct {
+	Obj   Expr
+	Index Expr
+}
+
+type Unary struct {
+	Op  string
+	Arg Expr
+}
+
+// A binary operator, including the logical
+// ones, && and ||.
+type Binary struct {
+	Op          string
+	Left, Right Expr
+}
+
+type Cond struct {
+	Test, Then, Else Expr
+}
+
+// An assignment, or a default value when
+// it's a parameter.
+type Assign struct {
+	Target, Value Expr
+}
+
+// Expressions separated by commas.
+type Seq struct {
+	Exprs []Expr
+}
+
+type Await struct {
+	Arg Expr
+}
+
+type Yield struct {
+	Arg      Expr
+	Delegate bool
+}
+
+// A function, or an arrow function. Arrows
+// return Expr if it's set, instead of a body.
+type Func struct {
+	Name      string
+	Params    []Expr
+	Body      []Stmt
+	Expr      Expr
+	Arrow     bool
+	Async     bool
+	Generator bool
+}
+
+// Raw JS, with Args between the Parts.
+//
+//	(js* "~{} instanceof ~{}" x Map)
+type Raw struct {
+	Parts []string
+	Args  []Expr
+}
+
+// Statements.
+
+type ExprStmt struct {
+	X Expr
+}
+
+// A declaration, where Kind is let, const or var.
+type VarDecl struct {
+	Kind  string
+	Decls []Declarator
+}
+
+// A binding, with an optional Init.
+type Declarator struct {
+	Target Expr
+	Init   Expr
+}
+
+type Block struct {
+	Body []Stmt
+}
+
+// An if, where Else is nil, a Block or an If.
+type If struct {
+	Test Expr
+	Then *Block
+	Else Stmt
+}
+
+type While struct {
+	Test Expr
+	Body *Block
+}
+
+type Labeled struct {
+	Label string
+	Body  Stmt
+}
+
+type Break struct {
+	Label string
+}
+
+type Continue struct {
+	Label string
+}
+
+// A return, with an optional Arg.
+type Return struct {
+	Arg Expr
+}
+
+type Throw struct {
+	Arg Expr
+}
+
+// A try, with a Handler, a Finalizer or both.
+type Try struct {
+	Block     *Block
+	Param     string
+	Handler   *Block
+	Finalizer *Block
+}
+
+type Switch struct {
+	Disc  Expr
+	Cases []Case
+}
+
+// A case, where a nil Test is the default.
+type Case struct {
+	Test Expr
+	Body []Stmt
+}
+
+type FuncDecl struct {
+	Func *Func
+}
+
+// Raw JS that is a statement.
+type RawStmt struct {
+	Raw *Raw
+}
+
+// A line comment, where every line of Text
+// is commented.
+type Comment struct {
+	Text string
+}
+
+// A string with the content s, quoted by Go
+// rules, which are valid in JS for the
+// strings that the transpiler makes.
+func Str(s string) *String {
+	q := strconv.Quote(s)
+	return &String{V: q[1 : len(q)-1]}
+}
+
+func (*Ident) node()  {}
+func (*Lit) node()    {}
+func (*String) node() {}
+func (*Array) node()  {}
+func (*Object) node() {}
+func (*Spread) node() {}
+func (*Call) node()   {}
+func (*New) node()    {}
+func (*Member) node() {}
+func (*Index) node()  {}
+func (*Unary) node()  {}
+func (*Binary) node() {}
+func (*Cond) node()   {}
+func (*Assign) node() {}
+func (*Seq) node()    {}
+func (*Await) node()  {}
+func (*Yield) node()  {}
+func (*Func) node()   {}
+func (*Raw) node()    {}
+
+func (*Ident) expr()  {}
+func (*Lit) expr()    {}
+func (*String) expr() {}
+func (*Array) expr()  {}
+func (*Object) expr() {}
+func (*Spread) expr() {}
+func (*Call) expr()   {}
+func (*New) expr()    {}
+func (*Member) expr() {}
+func (*Index) expr()  {}
+func (*Unary) expr()  {}
+func (*Binary) expr() {}
+func (*Cond) expr()   {}
+func (*Assign) expr() {}
+func (*Seq) expr()    {}
+func (*Await) expr()  {}
+func (*Yield) expr()  {}
+func (*Func) expr()   {}
+func (*Raw) expr()    {}
+
+func (*ExprStmt) node() {}
+func (*VarDecl) node()  {}
+func (*Block) node()    {}
+func (*If) node()       {}
+func (*While) node()    {}
+func (*Labeled) node()  {}
+func (*Break) node()    {}
+func (*Continue) node() {}
+func (*Return) node()   {}
+func (*Throw) node()    {}
+func (*Try) node()      {}
+func (*Switch) node()   {}
+func (*FuncDecl) node() {}
+func (*RawStmt) node()  {}
+func (*Comment) node()  {}
+
+func (*ExprStmt) stmt() {}
+func (*VarDecl) stmt()  {}
+func (*Block) stmt()    {}
+func (*If) stmt()       {}
+func (*While) stmt()    {}
+func (*Labeled) stmt()  {}
+func (*Break) stmt()    {}
+func (*Continue) stmt() {}
+func (*Return) stmt()   {}
+func (*Throw) stmt()    {}
+func (*Try) stmt()      {}
+func (*Switch) stmt()   {}
+func (*FuncDecl) stmt() {}
+func (*RawStmt) stmt()  {}
+func (*Comment) stmt()  {}
@@ -0,0 +1,741 @@
+package js
+
+import (
+	"strings"
+)
+
+// Options say how code is printed. The zero
+// value prints statements on a single line.
+type Options struct {
+	// The indentation of one level, such as two
+	// spaces. When it's set, every statement is
+	// on a line of its own.
+	Indent string
+
+	// The width that indented lines are kept
+	// within, by breaking lists of arguments and
+	// elements over lines. Zero doesn't break them.
+	Width int
+
+	// The quote of strings, " or '. Zero is ".
+	Quote byte
+
+	// Leaves out whitespace that isn't needed,
+	// and comments. Indent and Width are ignored.
+	Minify bool
+}
+
+// Prints statements as JS.
+func Print(stmts []Stmt, opts Options) string {
+	if opts.Minify {
+		opts.Indent, opts.Width = "", 0
+	}
+	p := &printer{opts: opts}
+	p.stmts(stmts)
+	return p.s.String()
+}
+
+// Prints an expression as JS, on a single line.
+func PrintExpr(x Expr) string {
+	p := &printer{}
+	p.expr(x, precRaw)
+	return p.s.String()
+}
+
+type printer struct {
+	opts  Options
+	s     strings.Builder
+	level int
+	// The column that the printer starts at, for
+	// printers of a part of a line.
+	start int
+	// Whether lists are kept on one line, when
+	// measuring them.
+	flat bool
+}
+
+// Operator precedence, where operands with a
+// lower precedence than their place need
+// parentheses.
+const (
+	precRaw = iota
+	precSeq
+	precAssign
+	precCond
+	precOr
+	precAnd
+	precBitOr
+	precBitXor
+	precBitAnd
+	precEquality
+	precRelational
+	precShift
+	precAdditive
+	precMultiplicative
+	precPow
+	precUnary
+	precPostfix
+	precCall
+	precPrimary
+)
+
+var binaryPrec = map[string]int{
+	"||": precOr, "??": precOr,
+	"&&": precAnd,
+	"|":  precBitOr,
+	"^":  precBitXor,
+	"&":  precBitAnd,
+	"==": precEquality, "!=": precEquality, "===": precEquality, "!==": precEquality,
+	"<": precRelational, ">": precRelational, "<=": precRelational, ">=": precRelational,
+	"instanceof": precRelational, "in": precRelational,
+	"<<": precShift, ">>": precShift, ">>>": precShift,
+	"+": precAdditive, "-": precAdditive,
+	"*": precMultiplicative, "/": precMultiplicative, "%": precMultiplicative,
+	"**": precPow,
+}
+
+func prec(x Expr) int {
+	switch x := x.(type) {
+	case *Lit:
+		if strings.HasPrefix(x.Raw, "-") {
+			return precUnary
+		}
+		return precPrimary
+	case *Ident, *String, *Array, *Object:
+		return precPrimary
+	case *Call, *New, *Member, *Index:
+		return precCall
+	case *Unary, *Await:
+		return precUnary
+	case *Binary:
+		return binaryPrec[x.Op]
+	case *Cond:
+		return precCond
+	case *Assign, *Yield, *Spread:
+		return precAssign
+	case *Func:
+		if x.Arrow {
+			return precAssign
+		}
+		return precPrimary
+	case *Seq:
+		return precSeq
+	default:
+		return precRaw
+	}
+}
+
+func (p *printer) write(ss ...string) {
+	for _, s := range ss {
+		p.s.WriteString(s)
+	}
+}
+
+// Writes a space that minified code leaves out.
+func (p *printer) space() {
+	if !p.opts.Minify {
+		p.write(" ")
+	}
+}
+
+// The column that the next write starts at.
+func (p *printer) col() int {
+	s := p.s.String()
+	if i := strings.LastIndexByte(s, '\n'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return p.start + len(s)
+}
+
+// A printer for a part of the current line,
+// which is written when it's done.
+func (p *printer) sub() *printer {
+	return &printer{opts: p.opts, level: p.level, start: p.col(), flat: p.flat}
+}
+
+// Prints x, in parentheses if its precedence
+// is lower than min.
+func (p *printer) expr(x Expr, min int) {
+	if prec(x) < min {
+		p.write("(")
+		p.expr(x, precRaw)
+		p.write(")")
+		return
+	}
+	switch x := x.(type) {
+	case *Ident:
+		p.write(x.Name)
+	case *Lit:
+		p.write(x.Raw)
+	case *String:
+		p.write(quote(x.V, p.opts.Quote))
+	case *Array:
+		p.list("[", "]", x.Elems)
+	case *Object:
+		p.object(x)
+	case *Spread:
+		p.write("...")
+		p.expr(x.Arg, precAssign)
+	case *Call:
+		p.callee(x.Callee)
+		p.list("(", ")", x.Args)
+	case *New:
+		p.write("new ")
+		if isPath(x.Callee) {
+			p.expr(x.Callee, precCall)
+		} else {
+			p.parens(x.Callee)
+		}
+		p.list("(", ")", x.Args)
+	case *Member:
+		p.callee(x.Obj)
+		p.write(".", x.Prop)
+	case *Index:
+		p.callee(x.Obj)
+		p.write("[")
+		p.expr(x.Index, precSeq)
+		p.write("]")
+	case *Unary:
+		p.unary(x)
+	case *Binary:
+		p.binary(x)
+	case *Cond:
+		p.expr(x.Test, precOr)
+		p.space()
+		p.write("?")
+		p.space()
+		p.expr(x.Then, precAssign)
+		p.space()
+		p.write(":")
+		p.space()
+		p.expr(x.Else, precAssign)
+	case *Assign:
+		p.expr(x.Target, precCall)
+		p.space()
+		p.write("=")
+		p.space()
+		p.expr(x.Value, precAssign)
+	case *Seq:
+		for i, x := range x.Exprs {
+			if i > 0 {
+				p.write(",")
+				p.space()
+			}
+			p.expr(x, precAssign)
+		}
+	case *Await:
+		p.write("await ")
+		p.expr(x.Arg, precUnary)
+	case *Yield:
+		p.write("yield")
+		if x.Delegate {
+			p.write("*")
+		}
+		if x.Arg != nil {
+			p.write(" ")
+			p.expr(x.Arg, precAssign)
+		}
+	case *Func:
+		p.fn(x)
+	case *Raw:
+		for i, part := range x.Parts {
+			p.write(part)
+			if i < len(x.Args) {
+				p.expr(x.Args[i], precCall)
+			}
+		}
+	}
+}
+
+func (p *printer) parens(x Expr) {
+	p.write("(")
+	p.expr(x, precRaw)
+	p.write(")")
+}
+
+// Prints a list of expressions between open and
+// close, with one on each line if they don't fit
+// on the line.
+//
+//	f(a, b)
+//
+//	f(
+//	  a,
+//	  b
+//	)
+func (p *printer) list(open, close string, xs []Expr) {
+	items := func(p *printer, sep string) {
+		for i, x := range xs {
+			if i > 0 {
+				p.write(",", sep)
+			}
+			p.expr(x, precAssign)
+		}
+	}
+	if len(xs) == 0 || !p.breaks(func(p *printer) {
+		p.write(open)
+		items(p, " ")
+		p.write(close)
+	}) {
+		p.write(open)
+		sep := " "
+		if p.opts.Minify {
+			sep = ""
+		}
+		items(p, sep)
+		p.write(close)
+		return
+	}
+	p.write(open)
+	p.level++
+	p.newline()
+	items(p, "\n"+strings.Repeat(p.opts.Indent, p.level))
+	p.level--
+	p.newline()
+	p.write(close)
+}
+
+// Whether what print prints, up to its first line
+// break, goes past the width of the line.
+func (p *printer) breaks(print func(p *printer)) bool {
+	if p.flat || p.opts.Width <= 0 || p.opts.Indent == "" || p.opts.Minify {
+		return false
+	}
+	sub := p.sub()
+	sub.flat = true
+	print(sub)
+	line, _, _ := strings.Cut(sub.s.String(), "\n")
+	return p.col()+len(line) > p.opts.Width
+}
+
+// Prints what is called or accessed, where
+// functions and numbers need parentheses.
+//
+//	(function () {})(), (1).toString()
+func (p *printer) callee(x Expr) {
+	switch x := x.(type) {
+	case *Func:
+		p.parens(x)
+		return
+	case *Lit:
+		if x.Raw != "null" && x.Raw != "true" && x.Raw != "false" {
+			p.parens(x)
+			return
+		}
+	}
+	p.expr(x, precCall)
+}
+
+// Whether x is a name or a property of one,
+// which new can call without parentheses.
+func isPath(x Expr) bool {
+	switch x := x.(type) {
+	case *Ident:
+		return true
+	case *Member:
+		return isPath(x.Obj)
+	}
+	return false
+}
+
+func (p *printer) unary(x *Unary) {
+	p.write(x.Op)
+	arg := p.sub()
+	arg.expr(x.Arg, precUnary)
+	code := arg.s.String()
+	// - -x isn't --x, and words need a space.
+	last := x.Op[len(x.Op)-1]
+	if collides(x.Op, code) || last >= 'a' && last <= 'z' {
+		p.write(" ")
+	}
+	p.write(code)
+}
+
+func (p *printer) binary(x *Binary) {
+	left, right := binaryPrec[x.Op], binaryPrec[x.Op]+1
+	if x.Op == "**" {
+		// Exponents are right associative,
+		// and can't have a unary base.
+		left, right = precPostfix, precPow
+	}
+	p.expr(x.Left, left)
+	word := x.Op[0] >= 'a' && x.Op[0] <= 'z'
+	if word || !p.opts.Minify {
+		p.write(" ", x.Op, " ")
+		p.expr(x.Right, right)
+		return
+	}
+	p.write(x.Op)
+	r := p.sub()
+	r.expr(x.Right, right)
+	code := r.s.String()
+	if collides(x.Op, code) {
+		p.write(" ")
+	}
+	p.write(code)
+}
+
+// Whether an operator and the code after it
+// would read as another operator, like - -1.
+func collides(op, code string) bool {
+	last := op[len(op)-1]
+	return (last == '-' || last == '+') && strings.HasPrefix(code, op[len(op)-1:])
+}
+
+func (p *printer) object(x *Object) {
+	if len(x.Props) == 0 {
+		p.write("{}")
+		return
+	}
+	props := func(p *printer, sep string) {
+		for i, prop := range x.Props {
+			if i > 0 {
+				p.write(",", sep)
+			}
+			if prop.Computed {
+				p.write("[")
+				p.expr(prop.Key, precAssign)
+				p.write("]")
+			} else {
+				p.expr(prop.Key, precPrimary)
+			}
+			p.write(":")
+			p.space()
+			p.expr(prop.Value, precAssign)
+		}
+	}
+	if p.breaks(func(p *printer) {
+		p.write("{ ")
+		props(p, " ")
+		p.write(" }")
+	}) {
+		p.write("{")
+		p.level++
+		p.newline()
+		props(p, "\n"+strings.Repeat(p.opts.Indent, p.level))
+		p.level--
+		p.newline()
+		p.write("}")
+		return
+	}
+	p.write("{")
+	p.space()
+	sep := " "
+	if p.opts.Minify {
+		sep = ""
+	}
+	props(p, sep)
+	p.space()
+	p.write("}")
+}
+
+func (p *printer) fn(x *Func) {
+	if x.Async {
+		p.write("async ")
+	}
+	if !x.Arrow {
+		p.write("function")
+		if x.Generator {
+			p.write("*")
+		}
+		if x.Name != "" {
+			p.write(" ", x.Name)
+		} else {
+			p.space()
+		}
+	}
+	p.list("(", ")", x.Params)
+	if x.Arrow {
+		p.space()
+		p.write("=>")
+	}
+	p.space()
+	if x.Arrow && x.Expr != nil {
+		if _, ok := x.Expr.(*Object); ok {
+			p.parens(x.Expr)
+		} else {
+			p.expr(x.Expr, precAssign)
+		}
+		return
+	}
+	p.block(x.Body)
+}
+
+func (p *printer) newline() {
+	p.write("\n", strings.Repeat(p.opts.Indent, p.level))
+}
+
+// Prints statements, one per line when indented,
+// separated by spaces otherwise. Functions at the
+// top level have blank lines around them.
+func (p *printer) stmts(stmts []Stmt) {
+	if p.opts.Minify {
+		for _, stmt := range stmts {
+			if _, ok := stmt.(*Comment); !ok {
+				p.stmt(stmt)
+			}
+		}
+		return
+	}
+	for i, stmt := range stmts {
+		if i > 0 {
+			if _, ok := stmts[i-1].(*Comment); ok || p.opts.Indent != "" {
+				if p.level == 0 && p.opts.Indent != "" && (isFunc(stmts[i-1]) || isFunc(stmt)) {
+					p.write("\n")
+				}
+				p.newline()
+			} else {
+				p.write(" ")
+			}
+		}
+		p.stmt(stmt)
+	}
+}
+
+func isFunc(stmt Stmt) bool {
+	_, ok := stmt.(*FuncDecl)
+	return ok
+}
+
+func (p *printer) block(stmts []Stmt) {
+	if len(stmts) == 0 || p.opts.Minify && !hasCode(stmts) {
+		p.write("{}")
+		return
+	}
+	p.write("{")
+	p.indented(stmts)
+	p.write("}")
+}
+
+// Whether stmts are more than comments.
+func hasCode(stmts []Stmt) bool {
+	for _, stmt := range stmts {
+		if _, ok := stmt.(*Comment); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Prints statements a level in, between
+// the braces of a block.
+func (p *printer) indented(stmts []Stmt) {
+	if p.opts.Minify {
+		p.stmts(stmts)
+		return
+	}
+	if p.opts.Indent == "" {
+		p.write(" ")
+		p.stmts(stmts)
+		if _, ok := stmts[len(stmts)-1].(*Comment); ok {
+			p.newline()
+		} else {
+			p.write(" ")
+		}
+		return
+	}
+	p.level++
+	p.newline()
+	p.stmts(stmts)
+	p.level--
+	p.newline()
+}
+
+// Writes a keyword followed by a parenthesized
+// expression, like if (x).
+func (p *printer) head(keyword string, x Expr) {
+	p.write(keyword)
+	p.space()
+	p.write("(")
+	p.expr(x, precSeq)
+	p.write(")")
+	p.space()
+}
+
+func (p *printer) stmt(stmt Stmt) {
+	switch stmt := stmt.(type) {
+	case *ExprStmt:
+		x := p.sub()
+		x.expr(stmt.X, precRaw)
+		code := x.s.String()
+		// Statements that start like declarations
+		// are expressions in parentheses.
+		if strings.HasPrefix(code, "{") || strings.HasPrefix(code, "function") ||
+			strings.HasPrefix(code, "async function") || strings.HasPrefix(code, "let [") {
+			code = "(" + code + ")"
+		}
+		p.write(code, ";")
+	case *VarDecl:
+		p.write(stmt.Kind, " ")
+		for i, d := range stmt.Decls {
+			if i > 0 {
+				p.write(",")
+				p.space()
+			}
+			p.expr(d.Target, precAssign)
+			if d.Init != nil {
+				p.space()
+				p.write("=")
+				p.space()
+				p.expr(d.Init, precAssign)
+			}
+		}
+		p.write(";")
+	case *Block:
+		p.block(stmt.Body)
+	case *If:
+		p.head("if", stmt.Test)
+		p.block(stmt.Then.Body)
+		switch els := stmt.Else.(type) {
+		case *If:
+			p.space()
+			p.write("else ")
+			p.stmt(els)
+		case *Block:
+			p.space()
+			p.write("else")
+			p.space()
+			p.block(els.Body)
+		}
+	case *While:
+		p.head("while", stmt.Test)
+		p.block(stmt.Body.Body)
+	case *Labeled:
+		p.write(stmt.Label, ":")
+		p.space()
+		p.stmt(stmt.Body)
+	case *Break:
+		p.jump("break", stmt.Label)
+	case *Continue:
+		p.jump("continue", stmt.Label)
+	case *Return:
+		p.write("return")
+		if stmt.Arg != nil {
+			p.write(" ")
+			p.expr(stmt.Arg, precSeq)
+		}
+		p.write(";")
+	case *Throw:
+		p.write("throw ")
+		p.expr(stmt.Arg, precSeq)
+		p.write(";")
+	case *Try:
+		p.write("try")
+		p.space()
+		p.block(stmt.Block.Body)
+		if stmt.Handler != nil {
+			p.space()
+			p.write("catch")
+			p.space()
+			p.write("(", stmt.Param, ")")
+			p.space()
+			p.block(stmt.Handler.Body)
+		}
+		if stmt.Finalizer != nil {
+			p.space()
+			p.write("finally")
+			p.space()
+			p.block(stmt.Finalizer.Body)
+		}
+	case *Switch:
+		p.switchStmt(stmt)
+	case *FuncDecl:
+		p.fn(stmt.Func)
+	case *RawStmt:
+		start := p.s.Len()
+		p.expr(stmt.Raw, precRaw)
+		code := strings.TrimSpace(p.s.String()[start:])
+		if !strings.HasSuffix(code, ";") && !strings.HasSuffix(code, "}") {
+			p.write(";")
+		}
+	case *Comment:
+		for i, line := range strings.Split(stmt.Text, "\n") {
+			if i > 0 {
+				p.newline()
+			}
+			p.write(strings.TrimRight("// "+line, " "))
+		}
+	}
+}
+
+func (p *printer) jump(keyword, label string) {
+	p.write(keyword)
+	if label != "" {
+		p.write(" ", label)
+	}
+	p.write(";")
+}
+
+// Cases without a body fall through to the
+// next, which they share a line with.
+//
+//	case 2: case 3: return x;
+func (p *printer) switchStmt(stmt *Switch) {
+	p.head("switch", stmt.Disc)
+	p.write("{")
+	p.level++
+	for i, c := range stmt.Cases {
+		if p.opts.Indent == "" || i > 0 && len(stmt.Cases[i-1].Body) == 0 {
+			p.space()
+		} else {
+			p.newline()
+		}
+		if c.Test == nil {
+			p.write("default:")
+		} else {
+			p.write("case ")
+			p.expr(c.Test, precSeq)
+			p.write(":")
+		}
+		if len(c.Body) == 0 {
+			continue
+		}
+		if p.opts.Indent == "" {
+			p.space()
+			p.stmts(c.Body)
+			continue
+		}
+		p.level++
+		p.newline()
+		p.stmts(c.Body)
+		p.level--
+	}
+	p.level--
+	if p.opts.Indent == "" {
+		p.space()
+		p.write("}")
+	} else {
+		p.newline()
+		p.write("}")
+	}
+}
+
+// The string with content v, between quotes q,
+// where v has the escapes of a string in double
+// quotes.
+//
+//	say "hi" => 'say "hi"'
+func quote(v string, q byte) string {
+	if q == 0 || q == '"' {
+		return `"` + v + `"`
+	}
+	var s strings.Builder
+	s.WriteByte(q)
+	for i := 0; i < len(v); i++ {
+		switch {
+		case v[i] == '\\' && i+1 < len(v):
+			if v[i+1] != '"' {
+				s.WriteByte('\\')
+			}
+			s.WriteByte(v[i+1])
+			i++
+		case v[i] == q:
+			s.WriteByte('\\')
+			s.WriteByte(q)
+		default:
+			s.WriteByte(v[i])
+		}
+	}
+	s.WriteByte(q)
+	return s.String()
+}
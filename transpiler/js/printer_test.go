@@ -0,0 +1,267 @@
+package js
+
+import (
+	"testing"
+)
+
+func TestPrintExpr(t *testing.T) {
+	a, b, c := &Ident{Name: "a"}, &Ident{Name: "b"}, &Ident{Name: "c"}
+	tests := []struct {
+		input  Expr
+		output string
+	}{
+		{
+			input:  &Binary{Op: "-", Left: &Binary{Op: "-", Left: a, Right: b}, Right: c},
+			output: "a - b - c",
+		},
+		{
+			input:  &Binary{Op: "-", Left: a, Right: &Binary{Op: "-", Left: b, Right: c}},
+			output: "a - (b - c)",
+		},
+		{
+			input:  &Binary{Op: "*", Left: &Binary{Op: "+", Left: a, Right: b}, Right: c},
+			output: "(a + b) * c",
+		},
+		{
+			input:  &Binary{Op: "**", Left: a, Right: &Binary{Op: "**", Left: b, Right: c}},
+			output: "a ** b ** c",
+		},
+		{
+			input:  &Binary{Op: "**", Left: &Lit{Raw: "-2"}, Right: &Lit{Raw: "2"}},
+			output: "(-2) ** 2",
+		},
+		{
+			input:  &Unary{Op: "-", Arg: &Lit{Raw: "-1"}},
+			output: "- -1",
+		},
+		{
+			input:  &Unary{Op: "!", Arg: &Binary{Op: "&&", Left: a, Right: b}},
+			output: "!(a && b)",
+		},
+		{
+			input:  &Unary{Op: "typeof", Arg: a},
+			output: "typeof a",
+		},
+		{
+			input:  &Cond{Test: a, Then: b, Else: &Cond{Test: b, Then: c, Else: a}},
+			output: "a ? b : b ? c : a",
+		},
+		{
+			input:  &Cond{Test: &Cond{Test: a, Then: b, Else: c}, Then: b, Else: c},
+			output: "(a ? b : c) ? b : c",
+		},
+		{
+			input:  &Call{Callee: &Ident{Name: "f"}, Args: []Expr{&Seq{Exprs: []Expr{a, b}}, c}},
+			output: "f((a, b), c)",
+		},
+		{
+			input:  &Member{Obj: &Lit{Raw: "1"}, Prop: "toString"},
+			output: "(1).toString",
+		},
+		{
+			input:  &Member{Obj: &Binary{Op: "+", Left: a, Right: b}, Prop: "length"},
+			output: "(a + b).length",
+		},
+		{
+			input:  &Call{Callee: &Func{Arrow: true, Expr: a}},
+			output: "(() => a)()",
+		},
+		{
+			input:  &New{Callee: &Call{Callee: &Ident{Name: "f"}}, Args: []Expr{a}},
+			output: "new (f())(a)",
+		},
+		{
+			input:  &New{Callee: &Member{Obj: a, Prop: "B"}},
+			output: "new a.B()",
+		},
+		{
+			input:  &Func{Arrow: true, Params: []Expr{a}, Expr: &Object{}},
+			output: "(a) => ({})",
+		},
+		{
+			input:  &Func{Async: true, Generator: true, Name: "g", Body: []Stmt{&Return{Arg: a}}},
+			output: "async function* g() { return a; }",
+		},
+		{
+			input:  &Await{Arg: &Call{Callee: a}},
+			output: "await a()",
+		},
+		{
+			input:  &Binary{Op: "+", Left: &Yield{Arg: a}, Right: b},
+			output: "(yield a) + b",
+		},
+		{
+			input:  &Object{Props: []Prop{{Key: a, Computed: true, Value: b}, {Key: Str("c"), Value: c}}},
+			output: "{ [a]: b, \"c\": c }",
+		},
+		{
+			input:  &Array{Elems: []Expr{a, &Spread{Arg: b}}},
+			output: "[a, ...b]",
+		},
+		{
+			input:  &Raw{Parts: []string{"", " ?? ", ""}, Args: []Expr{&Binary{Op: "||", Left: a, Right: b}, c}},
+			output: "(a || b) ?? c",
+		},
+		{
+			input:  Str("a \"b\"\n"),
+			output: "\"a \\\"b\\\"\\n\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			code := PrintExpr(tt.input)
+			if code != tt.output {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n", tt.output, code)
+			}
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	a, b := &Ident{Name: "a"}, &Ident{Name: "b"}
+	tests := []struct {
+		input  []Stmt
+		indent string
+		output string
+	}{
+		{
+			input:  []Stmt{&ExprStmt{X: &Object{}}},
+			output: "({});",
+		},
+		{
+			input:  []Stmt{&ExprStmt{X: &Func{Name: "f"}}},
+			output: "(function f() {});",
+		},
+		{
+			input:  []Stmt{&ExprStmt{X: &Seq{Exprs: []Expr{a, b}}}},
+			output: "a, b;",
+		},
+		{
+			input: []Stmt{
+				&VarDecl{Kind: "let", Decls: []Declarator{{Target: a}, {Target: b, Init: &Lit{Raw: "1"}}}},
+				&If{Test: a, Then: &Block{Body: []Stmt{&Return{Arg: a}}}, Else: &If{
+					Test: b, Then: &Block{Body: []Stmt{&Return{}}},
+				}},
+			},
+			output: "let a, b = 1; if (a) { return a; } else if (b) { return; }",
+		},
+		{
+			input: []Stmt{
+				&Labeled{Label: "l", Body: &While{Test: &Lit{Raw: "true"}, Body: &Block{
+					Body: []Stmt{&Break{Label: "l"}},
+				}}},
+			},
+			indent: "  ",
+			output: "l: while (true) {\n  break l;\n}",
+		},
+		{
+			input: []Stmt{
+				&Switch{Disc: a, Cases: []Case{
+					{Test: &Lit{Raw: "1"}},
+					{Test: &Lit{Raw: "2"}, Body: []Stmt{&Return{Arg: a}}},
+					{Body: []Stmt{&Throw{Arg: b}}},
+				}},
+			},
+			output: "switch (a) { case 1: case 2: return a; default: throw b; }",
+		},
+		{
+			input: []Stmt{
+				&Switch{Disc: a, Cases: []Case{
+					{Test: &Lit{Raw: "1"}},
+					{Test: &Lit{Raw: "2"}, Body: []Stmt{&Return{Arg: a}}},
+					{Body: []Stmt{&Throw{Arg: b}}},
+				}},
+			},
+			indent: "  ",
+			output: "switch (a) {\n  case 1: case 2:\n    return a;\n  default:\n    throw b;\n}",
+		},
+		{
+			input: []Stmt{
+				&Try{
+					Block:     &Block{Body: []Stmt{&ExprStmt{X: &Call{Callee: a}}}},
+					Param:     "e",
+					Handler:   &Block{},
+					Finalizer: &Block{Body: []Stmt{&ExprStmt{X: &Call{Callee: b}}}},
+				},
+			},
+			indent: "\t",
+			output: "try {\n\ta();\n} catch (e) {} finally {\n\tb();\n}",
+		},
+		{
+			input:  []Stmt{&Comment{Text: "a\nb"}, &ExprStmt{X: a}},
+			output: "// a\n// b\na;",
+		},
+		{
+			input: []Stmt{
+				&RawStmt{Raw: &Raw{Parts: []string{"debugger"}}},
+				&RawStmt{Raw: &Raw{Parts: []string{"if (", ") { f() }"}, Args: []Expr{a}}},
+			},
+			output: "debugger; if (a) { f() }",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			code := Print(tt.input, Options{Indent: tt.indent})
+			if code != tt.output {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n", tt.output, code)
+			}
+		})
+	}
+}
+
+func TestPrintOptions(t *testing.T) {
+	a, b := &Ident{Name: "a"}, &Ident{Name: "b"}
+	long := Str("a long string, long enough to break")
+	tests := []struct {
+		input  []Stmt
+		opts   Options
+		output string
+	}{
+		{
+			input:  []Stmt{&ExprStmt{X: &Call{Callee: &Ident{Name: "f"}, Args: []Expr{long, long}}}},
+			opts:   Options{Indent: "  ", Width: 40},
+			output: "f(\n  \"a long string, long enough to break\",\n  \"a long string, long enough to break\"\n);",
+		},
+		{
+			input:  []Stmt{&ExprStmt{X: &Call{Callee: &Ident{Name: "f"}, Args: []Expr{a, b}}}},
+			opts:   Options{Indent: "  ", Width: 40},
+			output: "f(a, b);",
+		},
+		{
+			input:  []Stmt{&ExprStmt{X: &Object{Props: []Prop{{Key: Str("k"), Value: long}}}}},
+			opts:   Options{Indent: "  ", Width: 40},
+			output: "({\n  \"k\": \"a long string, long enough to break\"\n});",
+		},
+		{
+			input:  []Stmt{&ExprStmt{X: Str("say \"hi\", it's")}},
+			opts:   Options{Quote: '\''},
+			output: "'say \"hi\", it\\'s';",
+		},
+		{
+			input: []Stmt{
+				&Comment{Text: "f"},
+				&FuncDecl{Func: &Func{Name: "f", Params: []Expr{a}, Body: []Stmt{
+					&Return{Arg: &Binary{Op: "-", Left: a, Right: &Lit{Raw: "-1"}}},
+				}}},
+				&If{Test: a, Then: &Block{Body: []Stmt{&ExprStmt{X: &Call{Callee: a}}}}, Else: &Block{
+					Body: []Stmt{&ExprStmt{X: &Unary{Op: "typeof", Arg: b}}},
+				}},
+			},
+			opts:   Options{Minify: true, Indent: "  "},
+			output: "function f(a){return a- -1;}if(a){a();}else{typeof b;}",
+		},
+		{
+			input:  []Stmt{&ExprStmt{X: &Func{Arrow: true, Params: []Expr{a}, Expr: &Binary{Op: "in", Left: a, Right: b}}}},
+			opts:   Options{Minify: true},
+			output: "(a)=>a in b;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			code := Print(tt.input, tt.opts)
+			if code != tt.output {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n", tt.output, code)
+			}
+		})
+	}
+}
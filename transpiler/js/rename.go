@@ -0,0 +1,453 @@
+package js
+
+import "strings"
+
+// Renames the parameters and locals of functions
+// to short names, for minified code. Names at the
+// top level are kept, since other code may use
+// them. So are the names of functions with raw JS
+// or eval in them, which may use names by text.
+//
+//	function add(first, second) { return first + second; }
+//
+//	function add(a, b) { return a + b; }
+func RenameLocals(stmts []Stmt) {
+	r := &renamer{taken: map[string]bool{}, frozen: true}
+	for _, stmt := range stmts {
+		walk(stmt, func(n Node) bool {
+			switch n := n.(type) {
+			case *Ident:
+				r.taken[first(n.Name)] = true
+			case *Func:
+				r.taken[n.Name] = true
+			case *Try:
+				r.taken[n.Param] = true
+			}
+			return true
+		})
+	}
+	for _, stmt := range stmts {
+		r.stmt(stmt)
+	}
+}
+
+type renamer struct {
+	// Every name in the code, which new
+	// names can't be.
+	taken map[string]bool
+	// The new names of the functions and blocks
+	// that the renamer is in, innermost last.
+	scopes []map[string]string
+	// The number of new names in use.
+	n int
+	// Whether names are kept, outside of
+	// functions and in opaque functions.
+	frozen bool
+}
+
+// Words that can't be names, or that mean
+// something as names.
+var reserved = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		arguments await break case catch class const continue debugger
+		default delete do else enum eval export extends false finally for
+		function if implements import in instanceof interface let new null
+		package private protected public return static super switch this
+		throw true try typeof undefined var void while with yield
+		Infinity NaN`) {
+		reserved[w] = true
+	}
+}
+
+const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// The next short name that isn't taken.
+//
+//	a, b, ..., Z, aa, ab, ...
+func (r *renamer) next() string {
+	for {
+		var name []byte
+		for i := r.n; ; i = i/len(letters) - 1 {
+			name = append([]byte{letters[i%len(letters)]}, name...)
+			if i < len(letters) {
+				break
+			}
+		}
+		r.n++
+		if s := string(name); !r.taken[s] && !reserved[s] {
+			return s
+		}
+	}
+}
+
+// The new name of name, if it's a local.
+func (r *renamer) lookup(name string) (string, bool) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if to, ok := r.scopes[i][name]; ok {
+			return to, true
+		}
+	}
+	return "", false
+}
+
+func (r *renamer) rename(name *string) {
+	if to, ok := r.lookup(*name); ok {
+		*name = to
+	}
+}
+
+func (r *renamer) ident(x *Ident) {
+	head, rest, dotted := strings.Cut(x.Name, ".")
+	if to, ok := r.lookup(head); ok {
+		if dotted {
+			to += "." + rest
+		}
+		x.Name = to
+	}
+}
+
+// Functions declare their parameters, their vars,
+// and the lets of their body. Function expressions
+// declare their own name too.
+func (r *renamer) fn(f *Func, decl bool) {
+	frozen := r.frozen
+	r.frozen = opaque(f)
+	r.scoped(func(bind func(string)) {
+		if !decl {
+			bind(f.Name)
+		}
+		for _, p := range f.Params {
+			bindings(p, bind)
+		}
+		for _, stmt := range f.Body {
+			vars(stmt, bind)
+		}
+		lets(f.Body, bind)
+	}, func() {
+		if !decl {
+			r.rename(&f.Name)
+		}
+		for _, p := range f.Params {
+			r.expr(p)
+		}
+		for _, stmt := range f.Body {
+			r.stmt(stmt)
+		}
+		if f.Expr != nil {
+			r.expr(f.Expr)
+		}
+	})
+	r.frozen = frozen
+}
+
+// Gives the names that declare binds new names,
+// while body is renamed. Scopes after this one can
+// use the same names again.
+func (r *renamer) scoped(declare func(bind func(string)), body func()) {
+	start := r.n
+	scope := map[string]string{}
+	if !r.frozen {
+		declare(func(name string) {
+			if _, ok := scope[name]; !ok && name != "" {
+				scope[name] = r.next()
+			}
+		})
+	}
+	r.scopes = append(r.scopes, scope)
+	body()
+	r.scopes = r.scopes[:len(r.scopes)-1]
+	r.n = start
+}
+
+// A block, where param is the parameter
+// of a catch, if it isn't nil.
+func (r *renamer) block(stmts []Stmt, param *string) {
+	r.scoped(func(bind func(string)) {
+		if param != nil {
+			bind(*param)
+		}
+		lets(stmts, bind)
+	}, func() {
+		if param != nil {
+			r.rename(param)
+		}
+		for _, stmt := range stmts {
+			r.stmt(stmt)
+		}
+	})
+}
+
+func (r *renamer) stmt(stmt Stmt) {
+	switch s := stmt.(type) {
+	case *Block:
+		r.block(s.Body, nil)
+	case *Try:
+		r.block(s.Block.Body, nil)
+		if s.Handler != nil {
+			r.block(s.Handler.Body, &s.Param)
+		}
+		if s.Finalizer != nil {
+			r.block(s.Finalizer.Body, nil)
+		}
+	case *Switch:
+		// The cases share a block.
+		r.expr(s.Disc)
+		r.scoped(func(bind func(string)) {
+			for _, c := range s.Cases {
+				lets(c.Body, bind)
+			}
+		}, func() {
+			for _, c := range s.Cases {
+				if c.Test != nil {
+					r.expr(c.Test)
+				}
+				for _, stmt := range c.Body {
+					r.stmt(stmt)
+				}
+			}
+		})
+	case *FuncDecl:
+		r.rename(&s.Func.Name)
+		r.fn(s.Func, true)
+	default:
+		children(stmt, func(n Node) {
+			switch n := n.(type) {
+			case Stmt:
+				r.stmt(n)
+			case Expr:
+				r.expr(n)
+			}
+		})
+	}
+}
+
+func (r *renamer) expr(x Expr) {
+	switch x := x.(type) {
+	case *Ident:
+		r.ident(x)
+	case *Func:
+		r.fn(x, false)
+	case *Object:
+		// Keys that aren't computed are names
+		// of properties.
+		for _, p := range x.Props {
+			if p.Computed {
+				r.expr(p.Key)
+			}
+			r.expr(p.Value)
+		}
+	default:
+		children(x, func(n Node) {
+			r.expr(n.(Expr))
+		})
+	}
+}
+
+// Calls bind with every name that a pattern binds.
+//
+//	[a, { [k]: b = 1 }, ...c] => a, b, c
+func bindings(x Expr, bind func(string)) {
+	switch x := x.(type) {
+	case *Ident:
+		bind(x.Name)
+	case *Array:
+		for _, e := range x.Elems {
+			bindings(e, bind)
+		}
+	case *Object:
+		for _, p := range x.Props {
+			bindings(p.Value, bind)
+		}
+	case *Assign:
+		bindings(x.Target, bind)
+	case *Spread:
+		bindings(x.Arg, bind)
+	}
+}
+
+// Calls bind with the names of the lets, consts
+// and functions of a block.
+func lets(stmts []Stmt, bind func(string)) {
+	for _, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *VarDecl:
+			if s.Kind != "var" {
+				for _, d := range s.Decls {
+					bindings(d.Target, bind)
+				}
+			}
+		case *FuncDecl:
+			bind(s.Func.Name)
+		}
+	}
+}
+
+// Calls bind with the names of the vars in stmt,
+// outside of nested functions.
+func vars(stmt Stmt, bind func(string)) {
+	switch s := stmt.(type) {
+	case *VarDecl:
+		if s.Kind == "var" {
+			for _, d := range s.Decls {
+				bindings(d.Target, bind)
+			}
+		}
+		return
+	case *FuncDecl, *ExprStmt, *Return, *Throw, *RawStmt:
+		return
+	}
+	children(stmt, func(n Node) {
+		if s, ok := n.(Stmt); ok {
+			vars(s, bind)
+		}
+	})
+}
+
+// Whether f has raw JS or a call to eval in it,
+// which can use names that aren't in the tree.
+func opaque(f *Func) bool {
+	found := false
+	walk(f, func(n Node) bool {
+		switch n := n.(type) {
+		case *Raw:
+			found = true
+		case *Call:
+			if name, ok := n.Callee.(*Ident); ok && name.Name == "eval" {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// The first part of a dotted name.
+func first(name string) string {
+	head, _, _ := strings.Cut(name, ".")
+	return head
+}
+
+// Calls f with n and every node in it, and
+// stops going into nodes that f returns false for.
+func walk(n Node, f func(Node) bool) {
+	if !f(n) {
+		return
+	}
+	children(n, func(c Node) {
+		walk(c, f)
+	})
+}
+
+// Calls f with the nodes directly in n.
+func children(n Node, f func(Node)) {
+	exprs := func(xs []Expr) {
+		for _, x := range xs {
+			f(x)
+		}
+	}
+	stmts := func(ss []Stmt) {
+		for _, s := range ss {
+			f(s)
+		}
+	}
+	switch n := n.(type) {
+	case *Array:
+		exprs(n.Elems)
+	case *Object:
+		for _, p := range n.Props {
+			f(p.Key)
+			f(p.Value)
+		}
+	case *Spread:
+		f(n.Arg)
+	case *Call:
+		f(n.Callee)
+		exprs(n.Args)
+	case *New:
+		f(n.Callee)
+		exprs(n.Args)
+	case *Member:
+		f(n.Obj)
+	case *Index:
+		f(n.Obj)
+		f(n.Index)
+	case *Unary:
+		f(n.Arg)
+	case *Binary:
+		f(n.Left)
+		f(n.Right)
+	case *Cond:
+		f(n.Test)
+		f(n.Then)
+		f(n.Else)
+	case *Assign:
+		f(n.Target)
+		f(n.Value)
+	case *Seq:
+		exprs(n.Exprs)
+	case *Await:
+		f(n.Arg)
+	case *Yield:
+		if n.Arg != nil {
+			f(n.Arg)
+		}
+	case *Func:
+		exprs(n.Params)
+		stmts(n.Body)
+		if n.Expr != nil {
+			f(n.Expr)
+		}
+	case *Raw:
+		exprs(n.Args)
+	case *ExprStmt:
+		f(n.X)
+	case *VarDecl:
+		for _, d := range n.Decls {
+			f(d.Target)
+			if d.Init != nil {
+				f(d.Init)
+			}
+		}
+	case *Block:
+		stmts(n.Body)
+	case *If:
+		f(n.Test)
+		f(n.Then)
+		if n.Else != nil {
+			f(n.Else)
+		}
+	case *While:
+		f(n.Test)
+		f(n.Body)
+	case *Labeled:
+		f(n.Body)
+	case *Return:
+		if n.Arg != nil {
+			f(n.Arg)
+		}
+	case *Throw:
+		f(n.Arg)
+	case *Try:
+		f(n.Block)
+		if n.Handler != nil {
+			f(n.Handler)
+		}
+		if n.Finalizer != nil {
+			f(n.Finalizer)
+		}
+	case *Switch:
+		f(n.Disc)
+		for _, c := range n.Cases {
+			if c.Test != nil {
+				f(c.Test)
+			}
+			stmts(c.Body)
+		}
+	case *FuncDecl:
+		f(n.Func)
+	case *RawStmt:
+		f(n.Raw)
+	}
+}
@@ -0,0 +1,79 @@
+package js
+
+import (
+	"testing"
+)
+
+func TestRenameLocals(t *testing.T) {
+	id := func(name string) *Ident { return &Ident{Name: name} }
+	tests := []struct {
+		input  []Stmt
+		output string
+	}{
+		{
+			input: []Stmt{
+				&VarDecl{Kind: "var", Decls: []Declarator{{Target: id("total"), Init: &Lit{Raw: "0"}}}},
+				&FuncDecl{Func: &Func{Name: "add", Params: []Expr{id("first"), id("second")}, Body: []Stmt{
+					&Return{Arg: &Binary{Op: "+", Left: id("first"), Right: id("second")}},
+				}}},
+			},
+			output: "var total = 0; function add(a, b) { return a + b; }",
+		},
+		{
+			input: []Stmt{
+				&FuncDecl{Func: &Func{Name: "f", Params: []Expr{id("a")}, Body: []Stmt{
+					&ExprStmt{X: &Call{Callee: id("console.log"), Args: []Expr{id("a.b")}}},
+				}}},
+			},
+			output: "function f(b) { console.log(b.b); }",
+		},
+		{
+			input: []Stmt{
+				&FuncDecl{Func: &Func{Name: "f", Body: []Stmt{
+					&Block{Body: []Stmt{
+						&VarDecl{Kind: "let", Decls: []Declarator{{Target: id("x"), Init: id("y")}}},
+					}},
+					&Return{Arg: id("x")},
+				}}},
+			},
+			output: "function f() { { let a = y; } return x; }",
+		},
+		{
+			input: []Stmt{
+				&FuncDecl{Func: &Func{Name: "f", Params: []Expr{id("x")}, Body: []Stmt{
+					&Return{Arg: &Object{Props: []Prop{{Key: id("x"), Value: id("x")}}}},
+				}}},
+			},
+			output: "function f(a) { return { x: a }; }",
+		},
+		{
+			input: []Stmt{
+				&FuncDecl{Func: &Func{Name: "f", Params: []Expr{id("x")}, Body: []Stmt{
+					&RawStmt{Raw: &Raw{Parts: []string{"return x"}}},
+				}}},
+			},
+			output: "function f(x) { return x; }",
+		},
+		{
+			input: []Stmt{
+				&ExprStmt{X: &Func{Arrow: true, Params: []Expr{id("x")}, Body: []Stmt{
+					&Try{
+						Block:   &Block{Body: []Stmt{&Return{Arg: id("x")}}},
+						Param:   "err",
+						Handler: &Block{Body: []Stmt{&Throw{Arg: id("err")}}},
+					},
+				}}},
+			},
+			output: "(a) => { try { return a; } catch (b) { throw b; } };",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			RenameLocals(tt.input)
+			code := Print(tt.input, Options{})
+			if code != tt.output {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n", tt.output, code)
+			}
+		})
+	}
+}
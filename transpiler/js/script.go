@@ -0,0 +1,67 @@
+package js
+
+import "regexp"
+
+var awaitWord = regexp.MustCompile(`\bawait\b`)
+
+// Whether stmts await outside of functions,
+// which only modules can do.
+func Awaits(stmts []Stmt) bool {
+	found := false
+	for _, stmt := range stmts {
+		walk(stmt, func(n Node) bool {
+			switch n := n.(type) {
+			case *Await:
+				found = true
+			case *Raw:
+				for _, part := range n.Parts {
+					found = found || awaitWord.MatchString(part)
+				}
+			case *Func:
+				return false
+			}
+			return !found
+		})
+	}
+	return found
+}
+
+// Stmts as a script, which can't await outside of
+// functions. Stmts that do are run in an async
+// function that returns the value of the last one,
+// after their declarations, which outlive it.
+//
+//	var x = await f(); x; => var x; (async () => { x = await f(); return x; })();
+func Script(stmts []Stmt) []Stmt {
+	if !Awaits(stmts) {
+		return stmts
+	}
+	var decls, body []Stmt
+	for i, stmt := range stmts {
+		switch s := stmt.(type) {
+		case *VarDecl:
+			decl := &VarDecl{Kind: s.Kind}
+			for _, d := range s.Decls {
+				bindings(d.Target, func(name string) {
+					decl.Decls = append(decl.Decls, Declarator{Target: &Ident{Name: name}})
+				})
+				if d.Init != nil {
+					body = append(body, &ExprStmt{X: &Assign{Target: d.Target, Value: d.Init}})
+				}
+			}
+			decls = append(decls, decl)
+		case *FuncDecl:
+			decls = append(decls, s)
+		case *ExprStmt:
+			if i == len(stmts)-1 {
+				body = append(body, &Return{Arg: s.X})
+			} else {
+				body = append(body, s)
+			}
+		default:
+			body = append(body, s)
+		}
+	}
+	fn := &Func{Arrow: true, Async: true, Body: body}
+	return append(decls, &ExprStmt{X: &Call{Callee: fn}})
+}
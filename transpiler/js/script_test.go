@@ -0,0 +1,51 @@
+package js
+
+import (
+	"testing"
+)
+
+func TestScript(t *testing.T) {
+	id := func(name string) *Ident { return &Ident{Name: name} }
+	await := &Await{Arg: &Call{Callee: id("f")}}
+	tests := []struct {
+		input  []Stmt
+		output string
+	}{
+		{
+			input: []Stmt{
+				&VarDecl{Kind: "var", Decls: []Declarator{{Target: id("x"), Init: &Call{Callee: id("f")}}}},
+				&ExprStmt{X: id("x")},
+			},
+			output: "var x = f(); x;",
+		},
+		{
+			input: []Stmt{
+				&ExprStmt{X: &Func{Arrow: true, Async: true, Expr: await}},
+			},
+			output: "async () => await f();",
+		},
+		{
+			input: []Stmt{
+				&FuncDecl{Func: &Func{Name: "g"}},
+				&VarDecl{Kind: "var", Decls: []Declarator{{Target: &Array{Elems: []Expr{id("a"), id("b")}}, Init: await}}},
+				&ExprStmt{X: &Call{Callee: id("g"), Args: []Expr{id("a")}}},
+				&ExprStmt{X: id("b")},
+			},
+			output: "function g() {} var a, b; (async () => { [a, b] = await f(); g(a); return b; })();",
+		},
+		{
+			input: []Stmt{
+				&RawStmt{Raw: &Raw{Parts: []string{"await f()"}}},
+			},
+			output: "(async () => { await f(); })();",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			code := Print(Script(tt.input), Options{})
+			if code != tt.output {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n", tt.output, code)
+			}
+		})
+	}
+}
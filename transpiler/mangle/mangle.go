@@ -0,0 +1,183 @@
+package mangle
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Characters that aren't valid in JS names are
+// escaped between dollar signs, except for dashes
+// which are common enough to get their own escape.
+// Underscores are left alone, as names such as
+// _atom refer to the runtime.
+//
+//	empty? => empty$QMARK$
+//	a-b    => a$_b
+//	a$b    => a$$b
+var escapes = map[rune]string{
+	'?':  "QMARK",
+	'!':  "BANG",
+	'*':  "STAR",
+	'<':  "LT",
+	'>':  "GT",
+	'=':  "EQ",
+	'/':  "SLASH",
+	'+':  "PLUS",
+	'%':  "PERCENT",
+	'&':  "AMP",
+	'\'': "SQUOTE",
+	'#':  "HASH",
+	':':  "COLON",
+	'@':  "AT",
+	'^':  "CARET",
+	'~':  "TILDE",
+	'|':  "BAR",
+}
+
+var unescapes = func() map[string]rune {
+	m := map[string]rune{}
+	for r, s := range escapes {
+		m[s] = r
+	}
+	return m
+}()
+
+// Words that can't be used as names in JS, or
+// can't be bound in strict mode, which get a
+// trailing dollar sign. JS's own are js/eval
+// and js/arguments.
+//
+//	class => class$
+var reserved = map[string]bool{
+	"arguments": true, "await": true, "break": true, "case": true, "catch": true, "class": true,
+	"const": true, "continue": true, "debugger": true, "default": true,
+	"delete": true, "do": true, "else": true, "enum": true, "eval": true, "export": true,
+	"extends": true, "finally": true, "for": true, "function": true, "if": true,
+	"implements": true, "import": true, "in": true, "instanceof": true,
+	"interface": true, "let": true, "new": true, "package": true, "private": true,
+	"protected": true, "public": true, "return": true, "static": true,
+	"super": true, "switch": true, "throw": true, "try": true, "typeof": true, "var": true,
+	"void": true, "while": true, "with": true, "yield": true,
+}
+
+// A JS name for a remlisp name. Every part of a
+// dotted name is mangled, but only the first can
+// be a reserved word.
+//
+//	empty?         => empty$QMARK$
+//	user.full-name => user.full$_name
+//	default        => default$
+func Name(s string) string {
+	parts := strings.Split(s, ".")
+	for i, part := range parts {
+		parts[i] = Property(part)
+	}
+	if reserved[parts[0]] {
+		parts[0] += "$"
+	}
+	return strings.Join(parts, ".")
+}
+
+// A JS property name for a remlisp name, which
+// may be a reserved word.
+//
+//	to-string => to$_string
+func Property(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '$':
+			b.WriteString("$$")
+		case r == '-':
+			b.WriteString("$_")
+		case escapes[r] != "":
+			b.WriteString(fmt.Sprintf("$%s$", escapes[r]))
+		case r == '_' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r)):
+			b.WriteRune(r)
+		default:
+			b.WriteString(fmt.Sprintf("$U%X$", r))
+		}
+	}
+	return b.String()
+}
+
+// The remlisp name for a mangled name, and false
+// if it isn't one.
+//
+//	empty$QMARK$ => empty?
+//	default$     => default
+func Demangle(s string) (string, bool) {
+	parts := strings.Split(s, ".")
+	for i, part := range parts {
+		if i == 0 && strings.HasSuffix(part, "$") {
+			if word := strings.TrimSuffix(part, "$"); reserved[word] {
+				parts[i] = word
+				continue
+			}
+		}
+		name, ok := demangle(part)
+		if !ok {
+			return s, false
+		}
+		parts[i] = name
+	}
+	return strings.Join(parts, "."), true
+}
+
+func demangle(s string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 >= len(s) {
+			return s, false
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+			continue
+		case '_':
+			b.WriteByte('-')
+			i++
+			continue
+		}
+		end := strings.IndexByte(s[i+1:], '$')
+		if end < 0 {
+			return s, false
+		}
+		escape := s[i+1 : i+1+end]
+		r, ok := unescapes[escape]
+		if !ok {
+			n, err := strconv.ParseInt(strings.TrimPrefix(escape, "U"), 16, 32)
+			if err != nil || !strings.HasPrefix(escape, "U") {
+				return s, false
+			}
+			r = rune(n)
+		}
+		b.WriteRune(r)
+		i += end + 1
+	}
+	return b.String(), true
+}
+
+var jsName = regexp.MustCompile(`[\p{L}_$][\p{L}\d_$]*(\.[\p{L}_$][\p{L}\d_$]*)*`)
+
+// Demangles every name in text that is mangled,
+// such as those in error messages and stack traces.
+//
+//	"empty$QMARK$ is not defined" => "empty? is not defined"
+func Text(text string) string {
+	return jsName.ReplaceAllStringFunc(text, func(s string) string {
+		if !strings.Contains(s, "$") {
+			return s
+		}
+		name, _ := Demangle(s)
+		return name
+	})
+}
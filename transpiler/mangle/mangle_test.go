@@ -0,0 +1,135 @@
+package mangle
+
+import "testing"
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "xs",
+			output: "xs",
+		},
+		{
+			input:  "empty?",
+			output: "empty$QMARK$",
+		},
+		{
+			input:  "emptyP",
+			output: "emptyP",
+		},
+		{
+			input:  "a-b",
+			output: "a$_b",
+		},
+		{
+			input:  "a_b",
+			output: "a_b",
+		},
+		{
+			input:  "->>",
+			output: "$_$GT$$GT$",
+		},
+		{
+			input:  "*earmuffs*",
+			output: "$STAR$earmuffs$STAR$",
+		},
+		{
+			input:  "<=>",
+			output: "$LT$$EQ$$GT$",
+		},
+		{
+			input:  "a/b+c",
+			output: "a$SLASH$b$PLUS$c",
+		},
+		{
+			input:  "$",
+			output: "$$",
+		},
+		{
+			input:  "class",
+			output: "class$",
+		},
+		{
+			input:  "class$",
+			output: "class$$",
+		},
+		{
+			input:  "super",
+			output: "super$",
+		},
+		{
+			input:  "eval",
+			output: "eval$",
+		},
+		{
+			input:  "arguments",
+			output: "arguments$",
+		},
+		{
+			input:  "user.first-name",
+			output: "user.first$_name",
+		},
+		{
+			input:  "this.default",
+			output: "this.default",
+		},
+		{
+			input:  "_hashMap.apply",
+			output: "_hashMap.apply",
+		},
+		{
+			input:  "snö☃",
+			output: "snö$U2603$",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Name(tt.input); got != tt.output {
+				t.Fatalf("expected %q, got %q", tt.output, got)
+			}
+			got, ok := Demangle(tt.output)
+			if !ok || got != tt.input {
+				t.Fatalf("expected %q to demangle to %q, got %q", tt.output, tt.input, got)
+			}
+		})
+	}
+}
+
+func TestDemangleInvalid(t *testing.T) {
+	for _, input := range []string{"$", "a$", "$foo", "a$NOPE$b", "a$QMARK"} {
+		t.Run(input, func(t *testing.T) {
+			if got, ok := Demangle(input); ok {
+				t.Fatalf("expected %q to be invalid, got %q", input, got)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "empty$QMARK$ is not defined",
+			output: "empty? is not defined",
+		},
+		{
+			input:  "at parse$_int (file:///out.js:3:9)",
+			output: "at parse-int (file:///out.js:3:9)",
+		},
+		{
+			input:  "costs $5 at $",
+			output: "costs $5 at $",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Text(tt.input); got != tt.output {
+				t.Fatalf("expected %q, got %q", tt.output, got)
+			}
+		})
+	}
+}
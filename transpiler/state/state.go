@@ -6,24 +6,24 @@ type State uint8
 
 const (
 	UNKNOWN State = iota
-	NORMAL
-	NO_SEMICOLON
-	IN_STATEMENT
 	IN_QUASI
+	IN_FN
+	IN_ASYNC_FN
+	IN_GEN_FN
 )
 
 func (s State) String() string {
 	switch s {
 	case UNKNOWN:
 		return "UNKNOWN"
-	case NORMAL:
-		return "NORMAL"
-	case NO_SEMICOLON:
-		return "NO_SEMICOLON"
-	case IN_STATEMENT:
-		return "IN_STATEMENT"
 	case IN_QUASI:
 		return "IN_QUASI"
+	case IN_FN:
+		return "IN_FN"
+	case IN_ASYNC_FN:
+		return "IN_ASYNC_FN"
+	case IN_GEN_FN:
+		return "IN_GEN_FN"
 	default:
 		panic(fmt.Errorf("unknown state: %d", s))
 	}
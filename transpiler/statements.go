@@ -0,0 +1,574 @@
+package transpiler
+
+import (
+	"fmt"
+	"slices"
+
+	e "github.com/fholmqvist/remlisp/err"
+	ex "github.com/fholmqvist/remlisp/expr"
+	"github.com/fholmqvist/remlisp/transpiler/js"
+)
+
+// The statements of a block being transpiled.
+// Expressions that need statements, such as an
+// if with a do in a branch, add them here before
+// the statement that uses their value.
+type block struct {
+	stmts []js.Stmt
+	// Temporaries, which are declared first.
+	temps []string
+	// Whether the block is a JS block, as opposed
+	// to statements that end up in another block.
+	scope bool
+	// Temporaries at the top level are vars, so
+	// that the REPL can declare them again.
+	top bool
+}
+
+func (t *Transpiler) pushBlock(scope bool) {
+	top := !scope && len(t.blocks) > 0 && t.blocks[len(t.blocks)-1].top
+	t.blocks = append(t.blocks, &block{scope: scope, top: top})
+}
+
+// Pops the innermost block, and returns its
+// statements. JS blocks declare their temporaries
+// first, and other blocks leave them to the block
+// they end up in.
+func (t *Transpiler) popBlock() []js.Stmt {
+	b := t.dropBlock()
+	if !b.scope {
+		parent := t.blocks[len(t.blocks)-1]
+		parent.temps = append(parent.temps, b.temps...)
+		return b.stmts
+	}
+	if len(b.temps) == 0 {
+		return b.stmts
+	}
+	decl := &js.VarDecl{Kind: "let"}
+	if b.top {
+		decl.Kind = "var"
+	}
+	for _, name := range b.temps {
+		decl.Decls = append(decl.Decls, js.Declarator{Target: &js.Ident{Name: name}})
+	}
+	return append([]js.Stmt{decl}, b.stmts...)
+}
+
+// Pops the innermost block, for statements
+// that are thrown away.
+func (t *Transpiler) dropBlock() *block {
+	b := t.blocks[len(t.blocks)-1]
+	t.blocks = t.blocks[:len(t.blocks)-1]
+	return b
+}
+
+func (t *Transpiler) emit(stmts ...js.Stmt) {
+	b := t.blocks[len(t.blocks)-1]
+	b.stmts = append(b.stmts, stmts...)
+}
+
+// A new temporary, declared in the innermost
+// JS block, and assigned to if code isn't nil.
+func (t *Transpiler) temp(code js.Expr) *js.Ident {
+	t.temps++
+	name := &js.Ident{Name: fmt.Sprintf("__t%d", t.temps)}
+	b := t.blocks[len(t.blocks)-1]
+	b.temps = append(b.temps, name.Name)
+	if code != nil {
+		t.emit(&js.ExprStmt{X: &js.Assign{Target: name, Value: code}})
+	}
+	return name
+}
+
+// Transpiles expr, keeping the statements it needs
+// apart from its value.
+func (t *Transpiler) transpileApart(expr ex.Expr) ([]js.Stmt, js.Expr, *e.Error) {
+	t.pushBlock(false)
+	code, err := t.transpile(expr)
+	stmts := t.popBlock()
+	return stmts, code, err
+}
+
+// Transpiles expr as the statements of a JS block.
+func (t *Transpiler) transpileBlock(expr ex.Expr, to target) (*js.Block, *e.Error) {
+	t.pushBlock(true)
+	err := t.transpileTo(expr, to)
+	return &js.Block{Body: t.popBlock()}, err
+}
+
+// Transpiles expr as a single expression, in
+// places that statements can't come before, such
+// as default values of parameters.
+func (t *Transpiler) transpileExpression(expr ex.Expr) (js.Expr, *e.Error) {
+	t.pushBlock(true)
+	code, err := t.transpile(expr)
+	stmts := t.popBlock()
+	if err != nil {
+		return nil, err
+	}
+	if len(stmts) == 0 {
+		return code, nil
+	}
+	return t.iife(expr, append(stmts, &js.Return{Arg: code})), nil
+}
+
+// Transpiles exprs in order. When one of them needs
+// statements, those before it are stored first, so
+// that they are still evaluated before it.
+//
+//	(f (g) (do (h) 1)) => __t1 = g(); h(); f(__t1, 1)
+func (t *Transpiler) transpileAll(exprs []ex.Expr) ([]js.Expr, *e.Error) {
+	codes := make([]js.Expr, len(exprs))
+	from := 0
+	for i, expr := range exprs {
+		stmts, code, err := t.transpileApart(expr)
+		if err != nil {
+			return nil, err
+		}
+		if len(stmts) > 0 {
+			for j := from; j < i; j++ {
+				if !isConstant(exprs[j]) {
+					codes[j] = t.temp(codes[j])
+				}
+			}
+			from = i
+			t.emit(stmts...)
+		}
+		codes[i] = code
+	}
+	return codes, nil
+}
+
+// Transpiles exprs that are only evaluated when
+// those before them are, such as the branches of
+// an if. When one after the first needs statements,
+// which can't come before it, nothing is kept and
+// false is returned, so that the caller can use
+// statements instead.
+func (t *Transpiler) transpileLazy(exprs []ex.Expr, each func(i int, expr ex.Expr) (js.Expr, *e.Error)) ([]js.Expr, bool, *e.Error) {
+	temps, labels := t.temps, t.labels
+	t.pushBlock(false)
+	codes := make([]js.Expr, len(exprs))
+	for i, expr := range exprs {
+		if i > 0 {
+			t.pushBlock(false)
+		}
+		code, err := each(i, expr)
+		if i > 0 {
+			stmts := t.popBlock()
+			if err == nil && len(stmts) > 0 {
+				t.dropBlock()
+				t.temps, t.labels = temps, labels
+				return nil, false, nil
+			}
+		}
+		if err != nil {
+			t.dropBlock()
+			return nil, false, err
+		}
+		codes[i] = code
+	}
+	t.emit(t.popBlock()...)
+	return codes, true, nil
+}
+
+// Where the value of an expression in statement
+// position goes.
+type target struct {
+	// Returns the value, assigns it to name, or
+	// discards it when neither is set.
+	ret  bool
+	name *js.Ident
+	// The loop to break out of, when the value
+	// is the value of a loop.
+	label string
+	// Whether recur is allowed, which it is in
+	// the tail position of a loop.
+	recur bool
+}
+
+// Sends code to the target. Statements without a
+// value, like while, send nil, which is nil in JS
+// too if the value is used.
+func (t *Transpiler) send(to target, code js.Expr) {
+	if code == nil && to.used() {
+		code = t.nilValue()
+	}
+	switch {
+	case to.ret:
+		t.emit(&js.Return{Arg: code})
+		return
+	case to.name != nil:
+		t.emit(&js.ExprStmt{X: &js.Assign{Target: to.name, Value: code}})
+	case code != nil:
+		t.emit(&js.ExprStmt{X: code})
+	}
+	if to.label != "" {
+		t.emit(&js.Break{Label: to.label})
+	}
+}
+
+// Whether the target uses the value.
+func (to target) used() bool {
+	return to.ret || to.name != nil
+}
+
+// The target for statements whose value is
+// discarded, such as all but the last of a do.
+var discard = target{}
+
+// Transpiles expr as statements, that send its
+// value to the target. Forms that are statements
+// in JS, like if, become statements, instead of
+// being stored in temporaries.
+//
+//	(if c (do (f) 1) 2) => if (_truthy(c)) { f(); return 1; } else { return 2; }
+func (t *Transpiler) transpileTo(expr ex.Expr, to target) *e.Error {
+	if list, ok := expr.(*ex.List); ok && len(list.V) > 0 {
+		switch list.V[0].String() {
+		case "recur":
+			if !to.recur {
+				return e.FromPosition(list.Pos(),
+					fmt.Sprintf("recur must be in tail position: %s", list))
+			}
+			return t.transpileRecur(list)
+		case "if":
+			var els ex.Expr = ex.Nil{P: list.P}
+			if len(list.V) == 4 {
+				els = list.V[3]
+			}
+			return t.transpileIfTo(list.V[1], list.V[2], els, to)
+		case "when", "unless":
+			return t.transpileTo(desugarWhen(list), to)
+		case "cond":
+			return t.transpileTo(desugarCond(list), to)
+		case "case":
+			return t.transpileCaseTo(list, to)
+		case "do":
+			return t.transpileDoTo(list, to)
+		case "try":
+			return t.transpileTryTo(list, to)
+		case "loop":
+			return t.transpileLoopTo(list, to)
+		case "while", "var":
+			code, err := t.transpile(list)
+			if err != nil {
+				return err
+			}
+			if list.V[0].String() == "while" || !to.used() {
+				code = nil
+			}
+			t.send(to, code)
+			return nil
+		case "throw":
+			_, err := t.transpile(list)
+			return err
+		}
+	}
+	code, err := t.transpile(expr)
+	if err != nil {
+		return err
+	}
+	if fn, ok := code.(*js.Func); ok && fn.Name != "" {
+		// Named functions are declarations.
+		t.emit(&js.FuncDecl{Func: fn})
+		code = nil
+		if to.used() {
+			code = &js.Ident{Name: fn.Name}
+		}
+	} else if !to.used() && (t.isNil(code) || isConstant(expr)) {
+		code = nil
+	}
+	t.send(to, code)
+	return nil
+}
+
+// A do that declares names is a block of its own,
+// so that two of them, like the expansions of two
+// each loops, can declare the same names. A do that
+// is a whole block, or at the top level, is not.
+//
+//	(do (var x 1) (f x)) => { let x = 1; f(x); }
+func (t *Transpiler) transpileDoTo(list *ex.List, to target) *e.Error {
+	rest := list.V[1:]
+	if len(rest) == 0 {
+		t.send(to, nil)
+		return nil
+	}
+	b := t.blocks[len(t.blocks)-1]
+	return t.transpileDoBlock(rest, to, b.top || b.scope && len(b.stmts) == 0)
+}
+
+// The forms of a do, in a block if they declare
+// names, unless whole is set.
+func (t *Transpiler) transpileDoBlock(exprs []ex.Expr, to target, whole bool) *e.Error {
+	t.pushBlock(false)
+	for _, expr := range exprs[:len(exprs)-1] {
+		if err := t.transpileTo(expr, discard); err != nil {
+			t.popBlock()
+			return err
+		}
+	}
+	err := t.transpileTo(exprs[len(exprs)-1], to)
+	stmts := t.popBlock()
+	if !whole && slices.ContainsFunc(stmts, declares) {
+		stmts = []js.Stmt{&js.Block{Body: stmts}}
+	}
+	t.emit(stmts...)
+	return err
+}
+
+func (t *Transpiler) transpileIfTo(test, then, els ex.Expr, to target) *e.Error {
+	cond, err := t.transpileCondition(test)
+	if err != nil {
+		return err
+	}
+	thenBlock, err := t.transpileBlock(then, to)
+	if err != nil {
+		return err
+	}
+	elsBlock, err := t.transpileBlock(els, to)
+	if err != nil {
+		return err
+	}
+	t.emit(ifStmt(cond, thenBlock, elsBlock))
+	return nil
+}
+
+// An if statement, where an else that is only
+// another if becomes an else if.
+//
+//	if (a) { ... } else if (b) { ... } else { ... }
+func ifStmt(cond js.Expr, then, els *js.Block) *js.If {
+	stmt := &js.If{Test: cond, Then: then}
+	if els == nil || len(els.Body) == 0 {
+		return stmt
+	}
+	if elsif, ok := els.Body[0].(*js.If); ok && len(els.Body) == 1 {
+		stmt.Else = elsif
+	} else {
+		stmt.Else = els
+	}
+	return stmt
+}
+
+// Cases dispatch on literals and atoms, which are
+// interned, with a switch. Without a default a
+// value that doesn't match is an error.
+//
+//	(case x 1 "one" (2 3) "two or three" "many")
+//
+//	switch (x) {
+//	  case 1: return "one";
+//	  case 2: case 3: return "two or three";
+//	  default: return "many";
+//	}
+func (t *Transpiler) transpileCaseTo(list *ex.List, to target) *e.Error {
+	v, err := t.transpile(list.V[1])
+	if err != nil {
+		return err
+	}
+	if !allSimple(list.V[1:2]) {
+		v = t.temp(v)
+	}
+	stmt := &js.Switch{Disc: v}
+	branch := func(test js.Expr, expr ex.Expr) *e.Error {
+		body, err := t.transpileBlock(expr, to)
+		if err != nil {
+			return err
+		}
+		// Declarations are scoped to their case.
+		stmts := body.Body
+		if slices.ContainsFunc(stmts, declares) {
+			stmts = []js.Stmt{body}
+		}
+		if !to.ret && to.label == "" {
+			stmts = append(stmts, &js.Break{})
+		}
+		stmt.Cases = append(stmt.Cases, js.Case{Test: test, Body: stmts})
+		return nil
+	}
+	clauses := list.V[2:]
+	for i := 0; i+1 < len(clauses); i += 2 {
+		values := []ex.Expr{clauses[i]}
+		if l, ok := clauses[i].(*ex.List); ok {
+			values = l.V
+		}
+		var tests []js.Expr
+		for _, v := range values {
+			if _, ok := v.(ex.Nil); ok {
+				tests = append(tests, &js.Lit{Raw: "null"}, &js.Ident{Name: "undefined"})
+				continue
+			}
+			code, err := t.transpile(v)
+			if err != nil {
+				return err
+			}
+			tests = append(tests, code)
+		}
+		for _, test := range tests[:len(tests)-1] {
+			stmt.Cases = append(stmt.Cases, js.Case{Test: test})
+		}
+		if err := branch(tests[len(tests)-1], clauses[i+1]); err != nil {
+			return err
+		}
+	}
+	if len(clauses)%2 == 1 {
+		if err := branch(nil, clauses[len(clauses)-1]); err != nil {
+			return err
+		}
+	} else {
+		msg := &js.Binary{Op: "+", Left: js.Str("no matching case: "), Right: v}
+		stmt.Cases = append(stmt.Cases, js.Case{Body: []js.Stmt{
+			&js.Throw{Arg: &js.New{Callee: &js.Ident{Name: "Error"}, Args: []js.Expr{msg}}},
+		}})
+	}
+	t.emit(stmt)
+	return nil
+}
+
+func (t *Transpiler) transpileTryTo(list *ex.List, to target) *e.Error {
+	to.recur = false
+	body, err := t.transpileBlock(list.V[1], to)
+	if err != nil {
+		return err
+	}
+	stmt := &js.Try{Block: body}
+	for _, expr := range list.V[2:] {
+		clause := expr.(*ex.List)
+		switch clause.V[0].String() {
+		case "catch":
+			handler, err := t.transpileBlock(clause.V[2], to)
+			if err != nil {
+				return err
+			}
+			stmt.Param = fixName(clause.V[1].String())
+			stmt.Handler = handler
+		case "finally":
+			cleanup, err := t.transpileBlock(clause.V[1], discard)
+			if err != nil {
+				return err
+			}
+			stmt.Finalizer = cleanup
+		}
+	}
+	t.emit(stmt)
+	return nil
+}
+
+// Loops are functions that recur in their tail
+// position, which becomes a while loop. Loops that
+// don't return their value break out of a label.
+// The bindings are carried between iterations,
+// and bound anew in each, like the parameters of
+// a call are. Bindings that come before others
+// are bound for them to use too.
+//
+//	(loop [i 0] (if (< i 10) (recur (+ i 1)) i))
+//
+//	{ let __t1 = 0; while (true) { let i = __t1;
+//	  if (i < 10) { [__t1] = [i + 1]; continue; } else { return i; }
+//	} }
+func (t *Transpiler) transpileLoopTo(list *ex.List, to target) *e.Error {
+	bindings := list.V[1].(*ex.Vec)
+	t.pushBlock(true)
+	var carriers []js.Expr
+	copies := &js.VarDecl{Kind: "let"}
+	lets := &js.VarDecl{Kind: "let"}
+	for i := 0; i < len(bindings.V); i += 2 {
+		name, err := t.transpileBinding(bindings.V[i])
+		if err != nil {
+			t.popBlock()
+			return err
+		}
+		stmts, v, err := t.transpileApart(bindings.V[i+1])
+		if err != nil {
+			t.popBlock()
+			return err
+		}
+		if len(stmts) > 0 {
+			if len(lets.Decls) > 0 {
+				t.emit(lets)
+			}
+			lets = &js.VarDecl{Kind: "let"}
+			t.emit(stmts...)
+		}
+		carrier, copy := t.carry(name)
+		carriers = append(carriers, carrier)
+		copies.Decls = append(copies.Decls, copy)
+		lets.Decls = append(lets.Decls, js.Declarator{Target: carrier, Init: v})
+		if i+2 < len(bindings.V) {
+			lets.Decls = append(lets.Decls, copy)
+		}
+	}
+	if len(lets.Decls) > 0 {
+		t.emit(lets)
+	}
+	loop := target{ret: to.ret, name: to.name, label: to.label}
+	if !to.ret {
+		t.labels++
+		loop.label = fmt.Sprintf("__loop%d", t.labels)
+	}
+	while, err := t.transpileLoopBody(carriers, []js.Stmt{copies}, list.V[2], loop)
+	if err != nil {
+		t.popBlock()
+		return err
+	}
+	if to.ret {
+		t.emit(while)
+	} else {
+		t.emit(&js.Labeled{Label: loop.label, Body: while})
+	}
+	t.emit(&js.Block{Body: t.popBlock()})
+	if to.label != "" && !to.ret {
+		t.emit(&js.Break{Label: to.label})
+	}
+	return nil
+}
+
+// A while loop of body, where recur assigns
+// to carriers, after the prologue binds.
+func (t *Transpiler) transpileLoopBody(carriers []js.Expr, prologue []js.Stmt, body ex.Expr, to target) (*js.While, *e.Error) {
+	t.recur = append(t.recur, carriers)
+	defer func() { t.recur = t.recur[:len(t.recur)-1] }()
+	to.recur = true
+	block, err := t.transpileBlock(body, to)
+	if err != nil {
+		return nil, err
+	}
+	var stmts []js.Stmt
+	for _, stmt := range prologue {
+		if decl, ok := stmt.(*js.VarDecl); !ok || len(decl.Decls) > 0 {
+			stmts = append(stmts, stmt)
+		}
+	}
+	block.Body = append(stmts, block.Body...)
+	return &js.While{Test: &js.Lit{Raw: "true"}, Body: block}, nil
+}
+
+// A new variable that carries the value of
+// binding between iterations, as what recur
+// assigns to, and the binding of its value.
+//
+//	[a, b]  => __t1, [a, b] = __t1
+//	x = 1   => __t1 = 1, x = __t1
+//	...xs   => ...__t1, xs = __t1
+func (t *Transpiler) carry(binding js.Expr) (js.Expr, js.Declarator) {
+	t.temps++
+	carrier := &js.Ident{Name: fmt.Sprintf("__t%d", t.temps)}
+	switch b := binding.(type) {
+	case *js.Assign:
+		return &js.Assign{Target: carrier, Value: b.Value}, js.Declarator{Target: b.Target, Init: carrier}
+	case *js.Spread:
+		return &js.Spread{Arg: carrier}, js.Declarator{Target: b.Arg, Init: carrier}
+	}
+	return carrier, js.Declarator{Target: binding, Init: carrier}
+}
+
+func declares(stmt js.Stmt) bool {
+	switch stmt.(type) {
+	case *js.VarDecl, *js.FuncDecl:
+		return true
+	}
+	return false
+}
@@ -2,76 +2,168 @@ package transpiler
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	e "github.com/fholmqvist/remlisp/err"
 	ex "github.com/fholmqvist/remlisp/expr"
 	"github.com/fholmqvist/remlisp/token/operator"
+	"github.com/fholmqvist/remlisp/transpiler/js"
+	"github.com/fholmqvist/remlisp/transpiler/mangle"
 	"github.com/fholmqvist/remlisp/transpiler/state"
 )
 
-// TODO: Work on converting all statements to expressions.
-//       Void becomes nil.
-
 type Transpiler struct {
 	exprs []ex.Expr
 	i     int
 
 	state []state.State
+
+	// The blocks that statements are added to,
+	// and counters for temporaries and labels.
+	blocks []*block
+	temps  int
+	labels int
+
+	// The bindings that recur assigns
+	// to, for the innermost loop.
+	recur [][]js.Expr
+
+	opts Options
+}
+
+// Options are compile modes, where the
+// zero value is the default.
+type Options struct {
+	// Use JS truthiness in conditionals, where 0, ""
+	// and NaN are falsy too. By default only nil and
+	// false are falsy.
+	JSTruthiness bool
+
+	// Compile nil to undefined instead of null.
+	// Both are nil when compared or printed.
+	NilUndefined bool
+
+	// Compile vector and map literals to persistent
+	// collections, and get to a lookup that works
+	// with them.
+	Persistent bool
 }
 
 func New() *Transpiler {
+	return NewWithOptions(Options{})
+}
+
+func NewWithOptions(opts Options) *Transpiler {
 	return &Transpiler{
 		i:     0,
 		state: []state.State{},
+		recur: [][]js.Expr{},
+		opts:  opts,
 	}
 }
 
+// Transpiles exprs to a JS script, with every
+// form on a line of its own, unless they await.
 func (t *Transpiler) Transpile(exprs []ex.Expr) (string, *e.Error) {
-	t.exprs = exprs
-	t.i = 0
-	t.state = []state.State{}
-	var s strings.Builder
+	t.reset(exprs)
+	forms := make([]string, 0, len(exprs))
+	var program []js.Stmt
 	for _, e := range t.exprs {
-		code, err := t.transpile(e)
+		stmts, err := t.transpileTop(e)
 		if err != nil {
 			return "", err
 		}
-		s.WriteString(code)
+		if len(stmts) > 0 {
+			forms = append(forms, js.Print(stmts, js.Options{}))
+		}
+		program = append(program, stmts...)
+	}
+	if js.Awaits(program) {
+		return js.Print(js.Script(program), js.Options{}), nil
+	}
+	return strings.Join(forms, "\n"), nil
+}
+
+// Transpiles exprs to the statements of
+// a program, for printers with options.
+func (t *Transpiler) TranspileProgram(exprs []ex.Expr) ([]js.Stmt, *e.Error) {
+	t.reset(exprs)
+	var program []js.Stmt
+	for _, e := range t.exprs {
+		stmts, err := t.transpileTop(e)
+		if err != nil {
+			return nil, err
+		}
+		program = append(program, stmts...)
 	}
-	return s.String(), nil
+	return program, nil
 }
 
+// Transpiles expr to a JS script.
 func (t *Transpiler) TranspileOne(expr ex.Expr) (string, *e.Error) {
-	t.exprs = []ex.Expr{expr}
-	t.i = 0
-	t.state = []state.State{}
-	var s strings.Builder
-	code, err := t.transpile(expr)
+	t.reset([]ex.Expr{expr})
+	stmts, err := t.transpileTop(expr)
 	if err != nil {
 		return "", err
 	}
-	s.WriteString(code)
-	return s.String(), nil
+	return js.Print(js.Script(stmts), js.Options{}), nil
+}
 
+func (t *Transpiler) reset(exprs []ex.Expr) {
+	t.exprs = exprs
+	t.i = 0
+	t.state = []state.State{}
+	t.blocks = []*block{}
+	t.temps = 0
+	t.labels = 0
+	t.recur = [][]js.Expr{}
 }
 
-func (t *Transpiler) transpile(expr ex.Expr) (string, *e.Error) {
+// A form at the top level, after the statements
+// it needs. Its value is last, for the REPL, which
+// evaluates code that awaits in a function.
+//
+//	(if c (do (f) 1) 2) => var __t1; if (_truthy(c)) { f(); __t1 = 1; } else { __t1 = 2; } __t1;
+func (t *Transpiler) transpileTop(expr ex.Expr) ([]js.Stmt, *e.Error) {
+	t.blocks = []*block{{scope: true, top: true}}
+	var code js.Expr
+	var err *e.Error
+	if _, ok := expr.(*ex.Fn); ok || isStatement(expr) {
+		err = t.transpileTo(expr, discard)
+	} else {
+		code, err = t.transpile(expr)
+	}
+	stmts := t.popBlock()
+	if err != nil {
+		return nil, err
+	}
+	if code == nil || len(stmts) > 0 && t.isNil(code) {
+		return stmts, nil
+	}
+	if len(stmts) > 0 && contains(expr, "await") {
+		code = t.iife(expr, append(stmts, &js.Return{Arg: code}))
+		return []js.Stmt{&js.ExprStmt{X: code}}, nil
+	}
+	return append(stmts, &js.ExprStmt{X: code}), nil
+}
+
+func (t *Transpiler) transpile(expr ex.Expr) (js.Expr, *e.Error) {
 	switch expr := expr.(type) {
 	case ex.Nil:
-		return "nil", nil
+		return t.nilValue(), nil
 	case ex.Int:
-		return fmt.Sprintf("%d", expr.V), nil
+		return &js.Lit{Raw: fmt.Sprintf("%d", expr.V)}, nil
 	case ex.Float:
-		return fmt.Sprintf("%f", expr.V), nil
+		return &js.Lit{Raw: fmt.Sprintf("%f", expr.V)}, nil
 	case ex.Bool:
-		return fmt.Sprintf("%t", expr.V), nil
+		return &js.Lit{Raw: fmt.Sprintf("%t", expr.V)}, nil
 	case ex.String:
-		return fmt.Sprintf(`"%s"`, expr.V), nil
+		return &js.String{V: expr.V}, nil
 	case ex.Identifier:
-		return fixName(expr.V), nil
+		return &js.Ident{Name: fixName(expr.V)}, nil
 	case ex.Atom:
-		return fmt.Sprintf("%q", expr.String()), nil
+		return call("_atom", js.Str(expr.V)), nil
 	case *ex.List:
 		return t.transpileList(expr)
 	case *ex.Vec:
@@ -95,15 +187,19 @@ func (t *Transpiler) transpile(expr ex.Expr) (string, *e.Error) {
 	case *ex.UnquoteSplicing:
 		return t.transpileUnquoteSplicing(expr)
 	case ex.Op:
-		return "", e.FromPosition(expr.Pos(), fmt.Sprintf("misplaced operator: %q", expr))
+		fn, ok := operatorFns[expr.Op]
+		if !ok {
+			return nil, e.FromPosition(expr.Pos(), fmt.Sprintf("misplaced operator: %q", expr))
+		}
+		return &js.Ident{Name: fn}, nil
 	default:
-		return "", e.FromPosition(expr.Pos(), fmt.Sprintf("unknown expression type: %T", expr))
+		return nil, e.FromPosition(expr.Pos(), fmt.Sprintf("unknown expression type: %T", expr))
 	}
 }
 
-func (t *Transpiler) transpileList(list *ex.List) (string, *e.Error) {
+func (t *Transpiler) transpileList(list *ex.List) (js.Expr, *e.Error) {
 	if len(list.V) == 0 {
-		return "()", nil
+		return &js.Array{}, nil
 	}
 	head := list.V[0].String()
 	op, err := operator.From(head)
@@ -115,349 +211,1182 @@ func (t *Transpiler) transpileList(list *ex.List) (string, *e.Error) {
 		return t.transpileDo(list)
 	case "var":
 		return t.transpileVar(list)
-	case "set":
+	case "set", "set!":
 		return t.transpileSet(list)
 	case "get":
 		return t.transpileGet(list)
 	case "if":
 		return t.transpileIf(list)
+	case "when", "unless":
+		return t.transpileIf(desugarWhen(list))
+	case "cond":
+		return t.transpileCond(list)
+	case "case", "try", "loop":
+		return t.transpileValue(list)
 	case "while":
 		return t.transpileWhile(list)
+	case "throw":
+		return t.transpileThrow(list)
+	case "await":
+		return t.transpileAwait(list)
+	case "yield":
+		return t.transpileYield(list)
+	case "typeof":
+		return t.transpileTypeof(list)
+	case "recur":
+		return nil, e.FromPosition(list.Pos(),
+			fmt.Sprintf("recur must be in tail position: %s", list))
 	case ".":
 		return t.transpileDotList(list)
+	case "new":
+		return t.transpileNew(list.V[1], list.V[2:])
+	case "js*":
+		return t.transpileRawJS(list)
 	default:
+		if _, ok := list.V[0].(ex.Identifier); ok && isInterop(head) {
+			return t.transpileInterop(list, head)
+		}
 		return t.transpileListRaw(list, head)
 	}
 }
 
-func (t *Transpiler) transpileListRaw(list *ex.List, head string) (string, *e.Error) {
-	var s strings.Builder
+func (t *Transpiler) transpileListRaw(list *ex.List, head string) (js.Expr, *e.Error) {
 	if _, ok := list.V[0].(ex.Identifier); ok {
-		s.WriteString(fixName(head))
-		s.WriteByte('(')
-		t.setState(state.NO_SEMICOLON)
-		rest := list.V[1:]
-		for i, expr := range rest {
-			code, err := t.transpile(expr)
-			if err != nil {
-				return "", err
+		codes, err := t.transpileAll(list.V[1:])
+		if err != nil {
+			return nil, err
+		}
+		return call(fixName(head), codes...), nil
+	} else if atom, ok := list.V[0].(ex.Atom); ok {
+		return t.transpileAtomLookup(list, atom)
+	} else if isCallable(list.V[0]) {
+		return t.transpileCallExpr(list)
+	} else {
+		codes, err := t.transpileAll(list.V)
+		if err != nil {
+			return nil, err
+		}
+		return &js.Array{Elems: codes}, nil
+	}
+}
+
+// Calls the function that an expression returns.
+//
+//	((comp f g) x) => comp(f, g)(x)
+//	((fn [x] x) 1) => ((x) => x)(1)
+func (t *Transpiler) transpileCallExpr(list *ex.List) (js.Expr, *e.Error) {
+	codes, err := t.transpileAll(list.V)
+	if err != nil {
+		return nil, err
+	}
+	return &js.Call{Callee: codes[0], Args: codes[1:]}, nil
+}
+
+// Atoms are lookup functions.
+//
+//	(:name user)         => _atom("name")(user)
+//	(:name user "anon")  => _atom("name")(user, "anon")
+func (t *Transpiler) transpileAtomLookup(list *ex.List, atom ex.Atom) (js.Expr, *e.Error) {
+	if len(list.V) < 2 || len(list.V) > 3 {
+		return nil, e.FromPosition(list.Pos(),
+			fmt.Sprintf("atom lookup requires one or two arguments: %s", list))
+	}
+	codes, err := t.transpileAll(list.V)
+	if err != nil {
+		return nil, err
+	}
+	return &js.Call{Callee: codes[0], Args: codes[1:]}, nil
+}
+
+// Interop forms, where the head says how
+// the arguments are used.
+//
+//	(.-length xs)  => xs.length
+//	(.push xs 1)   => xs.push(1)
+//	(Map. [[1 2]]) => new Map([[1, 2]])
+func (t *Transpiler) transpileInterop(list *ex.List, head string) (js.Expr, *e.Error) {
+	switch {
+	case strings.HasPrefix(head, ".-"):
+		if len(list.V) != 2 {
+			return nil, e.FromPosition(list.Pos(),
+				fmt.Sprintf("property access requires one object: %s", list))
+		}
+		code, err := t.transpile(list.V[1])
+		if err != nil {
+			return nil, err
+		}
+		return property(code, head[2:]), nil
+	case strings.HasPrefix(head, "."):
+		if len(list.V) < 2 {
+			return nil, e.FromPosition(list.Pos(),
+				fmt.Sprintf("method call requires an object: %s", list))
+		}
+		codes, err := t.transpileAll(list.V[1:])
+		if err != nil {
+			return nil, err
+		}
+		return &js.Call{Callee: property(codes[0], head[1:]), Args: codes[1:]}, nil
+	default:
+		class := ex.Identifier{V: strings.TrimSuffix(head, "."), P: list.V[0].Pos()}
+		return t.transpileNew(class, list.V[1:])
+	}
+}
+
+// A property of obj, by its JS name.
+//
+//	(.-my-prop x) => x.my$_prop
+func property(obj js.Expr, prop string) *js.Member {
+	return &js.Member{Obj: obj, Prop: mangle.Property(prop)}
+}
+
+// A constructor call.
+//
+//	(new Map [[1 2]]) => new Map([[1, 2]])
+//	(new (f) 1)       => new (f())(1)
+func (t *Transpiler) transpileNew(class ex.Expr, args []ex.Expr) (js.Expr, *e.Error) {
+	codes, err := t.transpileAll(append([]ex.Expr{class}, args...))
+	if err != nil {
+		return nil, err
+	}
+	return &js.New{Callee: codes[0], Args: codes[1:]}, nil
+}
+
+// Raw JS, where each ~{} is an argument. Fragments
+// that are statements are emitted before the code
+// that uses them, and are nil.
+//
+//	(js* "~{} instanceof ~{}" x Map) => x instanceof Map
+//	(js* "debugger")                 => debugger;
+func (t *Transpiler) transpileRawJS(list *ex.List) (js.Expr, *e.Error) {
+	template := strings.TrimSpace(list.V[1].(ex.String).V)
+	parts := strings.Split(template, "~{}")
+	args := list.V[2:]
+	if len(args) != len(parts)-1 {
+		return nil, e.FromPosition(list.Pos(),
+			fmt.Sprintf("js* expected %d arguments, got %d: %s", len(parts)-1, len(args), list))
+	}
+	codes, err := t.transpileAll(args)
+	if err != nil {
+		return nil, err
+	}
+	raw := &js.Raw{Parts: parts, Args: codes}
+	if isJSStatement(template) {
+		t.emit(&js.RawStmt{Raw: raw})
+		return t.nilValue(), nil
+	}
+	return raw, nil
+}
+
+// Chains properties and method calls.
+//
+//	(. (Array 10) (fill 1) length) => Array(10).fill(1).length
+func (t *Transpiler) transpileDotList(list *ex.List) (js.Expr, *e.Error) {
+	codes, err := t.transpileAll(list.V[1:])
+	if err != nil {
+		return nil, err
+	}
+	code := codes[0]
+	for i, c := range codes[1:] {
+		switch c := c.(type) {
+		case *js.Ident:
+			code = &js.Member{Obj: code, Prop: c.Name}
+		case *js.Call:
+			name, ok := c.Callee.(*js.Ident)
+			if !ok {
+				return nil, e.FromPosition(list.V[i+2].Pos(),
+					fmt.Sprintf("expected property or method call: %s", list.V[i+2]))
+			}
+			code = &js.Call{Callee: &js.Member{Obj: code, Prop: name.Name}, Args: c.Args}
+		default:
+			return nil, e.FromPosition(list.V[i+2].Pos(),
+				fmt.Sprintf("expected property or method call: %s", list.V[i+2]))
+		}
+	}
+	return code, nil
+}
+
+func (t *Transpiler) transpileBinaryOperation(list *ex.List, op operator.Operator) (js.Expr, *e.Error) {
+	args := list.V[1:]
+	switch {
+	case op.IsComparison():
+		return t.transpileComparison(list, op)
+	case len(args) == 0:
+		return t.transpileIdentity(list, op)
+	case op == operator.QUOT, op == operator.REM:
+		if len(args) != 2 {
+			return nil, e.FromPosition(list.Pos(), fmt.Sprintf("%s requires two arguments", op))
+		}
+	case op == operator.BIT_NOT:
+		if len(args) != 1 {
+			return nil, e.FromPosition(list.Pos(), fmt.Sprintf("%s requires one argument", op))
+		}
+	case op == operator.AND || op == operator.OR:
+		return t.transpileLogical(list, op)
+	}
+	codes, err := t.transpileAll(args)
+	if err != nil {
+		return nil, err
+	}
+	switch op {
+	case operator.QUOT:
+		return call("Math.trunc", &js.Binary{Op: "/", Left: codes[0], Right: codes[1]}), nil
+	case operator.BIT_NOT:
+		return &js.Unary{Op: "~", Arg: codes[0]}, nil
+	case operator.SUB:
+		if len(codes) == 1 {
+			return &js.Unary{Op: "-", Arg: codes[0]}, nil
+		}
+	case operator.DIV:
+		if len(codes) == 1 {
+			return &js.Binary{Op: "/", Left: &js.Lit{Raw: "1"}, Right: codes[0]}, nil
+		}
+	case operator.POW:
+		// Exponents are right associative.
+		code := codes[len(codes)-1]
+		for i := len(codes) - 2; i >= 0; i-- {
+			code = &js.Binary{Op: "**", Left: codes[i], Right: code}
+		}
+		return code, nil
+	}
+	return chain(op.JS(), codes), nil
+}
+
+// Operators without arguments return their identity.
+//
+//	(+) => 0
+//	(*) => 1
+func (t *Transpiler) transpileIdentity(list *ex.List, op operator.Operator) (js.Expr, *e.Error) {
+	switch op {
+	case operator.ADD:
+		return &js.Lit{Raw: "0"}, nil
+	case operator.MUL:
+		return &js.Lit{Raw: "1"}, nil
+	case operator.AND:
+		return &js.Lit{Raw: "true"}, nil
+	case operator.OR:
+		return t.nilValue(), nil
+	default:
+		return nil, e.FromPosition(list.Pos(), fmt.Sprintf("%s requires at least one argument", op))
+	}
+}
+
+// Comparisons are chained, with every operand
+// evaluated once, and != is true unless all
+// operands are equal.
+//
+//	(< a b c)   => a < b && b < c
+//	(< a (f) c) => (__t1 = a, __t2 = f(), __t3 = c, __t1 < __t2 && __t2 < __t3)
+//	(!= a b c)  => !(_equals(a, b) && _equals(b, c))
+func (t *Transpiler) transpileComparison(list *ex.List, op operator.Operator) (js.Expr, *e.Error) {
+	args := list.V[1:]
+	switch len(args) {
+	case 0:
+		return nil, e.FromPosition(list.Pos(), fmt.Sprintf("%s requires at least one argument", op))
+	case 1:
+		return &js.Lit{Raw: "true"}, nil
+	}
+	codes, err := t.transpileAll(args)
+	if err != nil {
+		return nil, err
+	}
+	var assigns []js.Expr
+	if len(args) > 2 && !allSimple(args[1:len(args)-1]) {
+		for i, code := range codes {
+			if isConstant(args[i]) {
+				continue
 			}
-			s.WriteString(code)
-			if i < len(rest)-1 {
-				s.WriteString(", ")
+			codes[i] = t.temp(nil)
+			assigns = append(assigns, &js.Assign{Target: codes[i], Value: code})
+		}
+	}
+	if op == operator.NEQ && len(args) == 2 {
+		return comparePair(op, args[0], args[1], codes[0], codes[1]), nil
+	}
+	cmp := op
+	if op == operator.NEQ {
+		cmp = operator.EQ
+	}
+	pairs := make([]js.Expr, len(args)-1)
+	for i := range pairs {
+		pairs[i] = comparePair(cmp, args[i], args[i+1], codes[i], codes[i+1])
+	}
+	code := chain("&&", pairs)
+	if op == operator.NEQ {
+		code = &js.Unary{Op: "!", Arg: code}
+	}
+	if len(assigns) > 0 {
+		return &js.Seq{Exprs: append(assigns, code)}, nil
+	}
+	return code, nil
+}
+
+// Comparisons with nil are loose, so that both
+// null and undefined from JS are nil. Equality is
+// structural, unless a side is primitive.
+//
+//	(= x nil) => x == null
+//	(= x 1)   => x === 1
+//	(= x y)   => _equals(x, y)
+func comparePair(op operator.Operator, left, right ex.Expr, lcode, rcode js.Expr) js.Expr {
+	if op != operator.EQ && op != operator.NEQ {
+		return &js.Binary{Op: op.JS(), Left: lcode, Right: rcode}
+	}
+	_, lnil := left.(ex.Nil)
+	_, rnil := right.(ex.Nil)
+	switch {
+	case lnil || rnil:
+		code := lcode
+		if lnil {
+			code = rcode
+		}
+		loose := "=="
+		if op == operator.NEQ {
+			loose = "!="
+		}
+		return &js.Binary{Op: loose, Left: code, Right: &js.Lit{Raw: "null"}}
+	case isPrimitive(left) || isPrimitive(right):
+		return &js.Binary{Op: op.JS(), Left: lcode, Right: rcode}
+	case op == operator.NEQ:
+		return &js.Unary{Op: "!", Arg: call("_equals", lcode, rcode)}
+	default:
+		return call("_equals", lcode, rcode)
+	}
+}
+
+// And and or return the value that decided them,
+// by Lisp truthiness, or JS truthiness if that is
+// the option. Operands that need statements make
+// them nested if statements.
+//
+//	(or x 1)        => _truthy(x) ? x : 1
+//	(or (f) 1)      => _truthy(__t1 = f()) ? __t1 : 1
+//	(and (< a b) c) => !_truthy(__t1 = a < b) ? __t1 : c
+func (t *Transpiler) transpileLogical(list *ex.List, op operator.Operator) (js.Expr, *e.Error) {
+	rest := list.V[1:]
+	codes, ok, err := t.transpileLazy(rest, func(_ int, expr ex.Expr) (js.Expr, *e.Error) {
+		return t.transpile(expr)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		name := t.temp(nil)
+		if err := t.transpileLogicalTo(rest, op, name); err != nil {
+			return nil, err
+		}
+		return name, nil
+	}
+	if t.opts.JSTruthiness || isBoolean(list) {
+		return chain(op.JS(), codes), nil
+	}
+	var name *js.Ident
+	tests := make([]js.Expr, len(codes)-1)
+	values := make([]js.Expr, len(codes)-1)
+	for i, code := range codes[:len(codes)-1] {
+		values[i] = code
+		if !allSimple(rest[i : i+1]) {
+			if name == nil {
+				name = t.temp(nil)
 			}
+			values[i] = name
+			code = &js.Assign{Target: name, Value: code}
 		}
-		t.restoreState()
-		if t.hasState(state.NO_SEMICOLON) {
-			s.WriteByte(')')
-		} else {
-			s.WriteString(");")
+		tests[i] = truthy(code)
+		if op == operator.AND {
+			tests[i] = &js.Unary{Op: "!", Arg: tests[i]}
 		}
-		return s.String(), nil
-	} else {
-		s.WriteByte('[')
-		for i, expr := range list.V {
-			code, err := t.transpile(expr)
+	}
+	code := codes[len(codes)-1]
+	for i := len(tests) - 1; i >= 0; i-- {
+		code = &js.Cond{Test: tests[i], Then: values[i], Else: code}
+	}
+	return code, nil
+}
+
+// Assigns operands to name until one decides the
+// value, with each after the first in an if.
+//
+//	(or a (do (f) b)) => __t1 = a; if (!_truthy(__t1)) { f(); __t1 = b; }
+func (t *Transpiler) transpileLogicalTo(exprs []ex.Expr, op operator.Operator, name *js.Ident) *e.Error {
+	if err := t.transpileTo(exprs[0], target{name: name}); err != nil {
+		return err
+	}
+	if len(exprs) == 1 {
+		return nil
+	}
+	t.pushBlock(true)
+	err := t.transpileLogicalTo(exprs[1:], op, name)
+	stmts := t.popBlock()
+	if err != nil {
+		return err
+	}
+	var cond js.Expr = name
+	if !t.opts.JSTruthiness {
+		cond = truthy(name)
+	}
+	if op == operator.OR {
+		cond = &js.Unary{Op: "!", Arg: cond}
+	}
+	t.emit(ifStmt(cond, &js.Block{Body: stmts}, nil))
+	return nil
+}
+
+// Conditions use Lisp truthiness, where only nil
+// and false are falsy, unless statically boolean.
+//
+//	(if x ...)       => _truthy(x) ? ...
+//	(if (< x 1) ...) => x < 1 ? ...
+func (t *Transpiler) transpileCondition(expr ex.Expr) (js.Expr, *e.Error) {
+	if t.opts.JSTruthiness || isBoolean(expr) {
+		return t.transpile(expr)
+	}
+	if list, ok := expr.(*ex.List); ok && len(list.V) > 1 {
+		if op := list.V[0].String(); op == "and" || op == "or" {
+			jsop := "&&"
+			if op == "or" {
+				jsop = "||"
+			}
+			codes, ok, err := t.transpileLazy(list.V[1:], func(_ int, expr ex.Expr) (js.Expr, *e.Error) {
+				return t.transpileCondition(expr)
+			})
 			if err != nil {
-				return "", err
+				return nil, err
 			}
-			s.WriteString(code)
-			if i < len(list.V)-1 {
-				s.WriteString(", ")
+			if ok {
+				return chain(jsop, codes), nil
 			}
 		}
-		s.WriteByte(']')
-		return s.String(), nil
 	}
+	code, err := t.transpile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return truthy(code), nil
 }
 
-func (t *Transpiler) transpileDotList(list *ex.List) (string, *e.Error) {
-	var s strings.Builder
-	t.setState(state.NO_SEMICOLON)
+func (t *Transpiler) transpileFn(fn *ex.Fn) (js.Expr, *e.Error) {
+	t.setFnState(fn.Async, fn.Generator)
 	defer t.restoreState()
-	rest := list.V[1:]
-	for i, expr := range rest {
-		code, err := t.transpile(expr)
+	f := &js.Func{Name: fixName(fn.Name), Async: fn.Async, Generator: fn.Generator}
+	if len(fn.Arities) > 0 {
+		body, err := t.transpileArities(fn.Name, fn.Arities)
 		if err != nil {
-			return "", err
-		}
-		s.WriteString(code)
-		if i < len(rest)-1 {
-			s.WriteByte('.')
+			return nil, err
 		}
+		f.Params, f.Body = []js.Expr{&js.Spread{Arg: &js.Ident{Name: "__args"}}}, body
+		return f, nil
 	}
-	return s.String(), nil
+	sig, err := t.transpileSignature(fn.Params)
+	if err != nil {
+		return nil, err
+	}
+	body, err := t.transpileFnBody(sig, fn.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.Params, f.Body = sig.params, body
+	return f, nil
 }
 
-func (t *Transpiler) transpileBinaryOperation(e *ex.List, op operator.Operator) (string, *e.Error) {
-	t.setState(state.NO_SEMICOLON)
+func (t *Transpiler) transpileAnonymousFn(fn *ex.AnonymousFn) (js.Expr, *e.Error) {
+	// Arrow functions don't bind this, so
+	// functions that use it can't be arrows.
+	method := !fn.Generator && usesThis(fn)
+	f := &js.Func{Async: fn.Async, Generator: fn.Generator, Arrow: !fn.Generator && !method}
+	t.setFnState(fn.Async, fn.Generator)
 	defer t.restoreState()
-	opstr := op.String()
-	if opstr == "=" {
-		opstr = "=="
-	} else if opstr == "and" {
-		opstr = "&&"
-	} else if opstr == "or" {
-		opstr = "||"
-	}
-	var s strings.Builder
-	s.WriteByte('(')
-	for i, expr := range e.V[1:] {
-		code, err := t.transpile(expr)
+	if len(fn.Arities) > 0 {
+		body, err := t.transpileArities("fn", fn.Arities)
 		if err != nil {
-			return "", err
-		}
-		s.WriteString(code)
-		if i < len(e.V)-2 {
-			s.WriteString(fmt.Sprintf(" %s ", opstr))
+			return nil, err
 		}
+		f.Params, f.Body = []js.Expr{&js.Spread{Arg: &js.Ident{Name: "__args"}}}, body
+		return f, nil
+	}
+	sig, err := t.transpileSignature(fn.Params)
+	if err != nil {
+		return nil, err
+	}
+	body, err := t.transpileFnBody(sig, fn.Body)
+	if err != nil {
+		return nil, err
 	}
-	s.WriteByte(')')
-	return s.String(), nil
+	f.Params, f.Body = sig.params, body
+	if code, ok := returnsOnly(body); ok && f.Arrow {
+		f.Body, f.Expr = nil, code
+	}
+	return f, nil
+}
+
+// The parameters of a function, or of one of its
+// arities, and how many arguments it takes.
+type signature struct {
+	params []js.Expr
+	// Binds keyword options, [x & {:keys [verbose]}].
+	prologue js.Stmt
+	min      int
+	// Negative when variadic.
+	max int
 }
 
-func (t *Transpiler) transpileFn(fn *ex.Fn) (string, *e.Error) {
-	var s strings.Builder
-	s.WriteString(fmt.Sprintf("function %s(", fixName(fn.Name)))
-	for i, p := range fn.Params.V {
-		pstr, err := t.transpile(p)
+func (t *Transpiler) transpileSignature(params *ex.Vec) (signature, *e.Error) {
+	sig := signature{params: make([]js.Expr, len(params.V))}
+	defaults := false
+	for i, p := range params.V {
+		switch p := p.(type) {
+		case *ex.VariableArg:
+			if opts, ok := p.V.(*ex.Map); ok {
+				pattern, err := t.transpileMapBinding(opts)
+				if err != nil {
+					return sig, err
+				}
+				name := &js.Ident{Name: "__opts"}
+				sig.params[i] = &js.Spread{Arg: name}
+				sig.prologue = &js.VarDecl{Kind: "let", Decls: []js.Declarator{
+					{Target: pattern, Init: call("_kwargs", name)},
+				}}
+			} else {
+				sig.params[i] = &js.Spread{Arg: &js.Ident{Name: fixName(p.V.String())}}
+			}
+			sig.max = -1
+			continue
+		case *ex.List:
+			defaults = true
+		default:
+			if !defaults {
+				sig.min++
+			}
+		}
+		code, err := t.transpileBinding(p)
 		if err != nil {
-			return "", err
+			return sig, err
 		}
-		s.WriteString(pstr)
-		if i < len(fn.Params.V)-1 {
-			s.WriteString(", ")
+		sig.params[i] = code
+		sig.max++
+	}
+	return sig, nil
+}
+
+// The statements of a function body, which
+// returns its value, or loops if it recurs.
+// Loops carry the parameters that are names
+// from where they're bound, and those that
+// are patterns are replaced by carriers.
+//
+//	function f(n, [a]) {
+//	  let __t1 = n; while (true) { let n = __t1, [a] = __t2; ... }
+//	} => function f(n, __t2)
+func (t *Transpiler) transpileFnBody(sig signature, body ex.Expr) ([]js.Stmt, *e.Error) {
+	if recurs(body) {
+		t.pushBlock(true)
+		carriers := make([]js.Expr, len(sig.params))
+		copies := &js.VarDecl{Kind: "let"}
+		lets := &js.VarDecl{Kind: "let"}
+		for i, param := range sig.params {
+			carrier, copy := t.carry(param)
+			carriers[i], copies.Decls = carrier, append(copies.Decls, copy)
+			if _, ok := copy.Target.(*js.Ident); ok {
+				lets.Decls = append(lets.Decls, js.Declarator{Target: copy.Init, Init: copy.Target})
+			} else {
+				sig.params[i] = carrier
+			}
+		}
+		if len(lets.Decls) > 0 {
+			t.emit(lets)
+		}
+		prologue := []js.Stmt{copies}
+		if sig.prologue != nil {
+			prologue = append(prologue, sig.prologue)
 		}
+		loop, err := t.transpileLoopBody(carriers, prologue, body, target{ret: true})
+		if err != nil {
+			t.popBlock()
+			return nil, err
+		}
+		t.emit(loop)
+		return t.popBlock(), nil
 	}
-	s.WriteString(") { return ")
-	body, err := t.transpile(fn.Body)
-	if err != nil {
-		return "", err
+	t.pushBlock(true)
+	if sig.prologue != nil {
+		t.emit(sig.prologue)
 	}
-	s.WriteString(body)
-	s.WriteString(" }\n\n")
-	return s.String(), nil
+	err := t.transpileTo(body, target{ret: true})
+	return t.popBlock(), err
 }
 
-func (t *Transpiler) transpileAnonymousFn(fn *ex.AnonymousFn) (string, *e.Error) {
-	var s strings.Builder
-	s.WriteByte('(')
-	for i, p := range fn.Params.V {
-		pstr, err := t.transpile(p)
+// Multi-arity functions pick their body by
+// the number of arguments.
+//
+//	(fn area ([r] ...) ([w h] ...))
+//
+//	function area(...__args) {
+//	  if (__args.length === 1) { let [r] = __args; return ...; }
+//	  if (__args.length === 2) { let [w, h] = __args; return ...; }
+//	  throw _arityError("area", __args.length, "1 or 2");
+//	}
+func (t *Transpiler) transpileArities(name string, arities []ex.Arity) ([]js.Stmt, *e.Error) {
+	args := &js.Ident{Name: "__args"}
+	length := &js.Member{Obj: args, Prop: "length"}
+	compare := func(op string, n int) js.Expr {
+		return &js.Binary{Op: op, Left: length, Right: &js.Lit{Raw: fmt.Sprint(n)}}
+	}
+	var stmts []js.Stmt
+	expected := make([]string, len(arities))
+	for i, a := range arities {
+		sig, err := t.transpileSignature(a.Params)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		s.WriteString(pstr)
-		if i < len(fn.Params.V)-1 {
-			s.WriteString(", ")
+		var cond js.Expr
+		switch {
+		case sig.max < 0:
+			cond = compare(">=", sig.min)
+			expected[i] = fmt.Sprintf("%d or more", sig.min)
+		case sig.min == sig.max:
+			cond = compare("===", sig.min)
+			expected[i] = fmt.Sprint(sig.min)
+		default:
+			cond = &js.Binary{Op: "&&", Left: compare(">=", sig.min), Right: compare("<=", sig.max)}
+			expected[i] = fmt.Sprintf("%d to %d", sig.min, sig.max)
 		}
+		var body []js.Stmt
+		if len(sig.params) > 0 {
+			body = append(body, &js.VarDecl{Kind: "let", Decls: []js.Declarator{
+				{Target: &js.Array{Elems: sig.params}, Init: args},
+			}})
+		}
+		stmts2, err := t.transpileFnBody(sig, a.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, stmts2...)
+		stmts = append(stmts, &js.If{Test: cond, Then: &js.Block{Body: body}})
 	}
-	s.WriteString(") => ")
-	body, err := t.transpile(fn.Body)
-	if err != nil {
-		return "", err
+	stmts = append(stmts, &js.Throw{Arg: call("_arityError",
+		js.Str(name), length, js.Str(joinOr(expected)))})
+	return stmts, nil
+}
+
+// Transpiles the left hand side of a binding,
+// such as a parameter or a loop binding.
+//
+//	x                             => x
+//	[a b & rest]                  => [a, b, ...rest]
+//	(y 10)                        => y = 10
+//	{:keys [a] :or {a 1} b :b}    => { [_atom("a")]: a = 1, [_atom("b")]: b }
+func (t *Transpiler) transpileBinding(expr ex.Expr) (js.Expr, *e.Error) {
+	switch expr := expr.(type) {
+	case ex.Identifier:
+		return &js.Ident{Name: fixName(expr.V)}, nil
+	case *ex.VariableArg:
+		if _, ok := expr.V.(*ex.Map); ok {
+			return nil, e.FromPosition(expr.Pos(),
+				fmt.Sprintf("keyword options are only allowed as parameters: %s", expr))
+		}
+		return &js.Spread{Arg: &js.Ident{Name: fixName(expr.V.String())}}, nil
+	case *ex.Vec:
+		elems := make([]js.Expr, len(expr.V))
+		for i, b := range expr.V {
+			code, err := t.transpileBinding(b)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = code
+		}
+		return &js.Array{Elems: elems}, nil
+	case *ex.Map:
+		return t.transpileMapBinding(expr)
+	case *ex.List:
+		if len(expr.V) != 2 {
+			return nil, e.FromPosition(expr.Pos(),
+				fmt.Sprintf("expected binding and default value: %s", expr))
+		}
+		b, err := t.transpileBinding(expr.V[0])
+		if err != nil {
+			return nil, err
+		}
+		v, err := t.transpileExpression(expr.V[1])
+		if err != nil {
+			return nil, err
+		}
+		return &js.Assign{Target: b, Value: v}, nil
+	default:
+		return nil, e.FromPosition(expr.Pos(),
+			fmt.Sprintf("expected binding: %s", expr))
 	}
-	s.WriteString(body)
-	return s.String(), nil
 }
 
-func (t *Transpiler) transpileIf(list *ex.List) (string, *e.Error) {
-	t.setState(state.NO_SEMICOLON)
-	defer t.restoreState()
-	var s strings.Builder
-	s.WriteString("(() => ")
-	cond, err := t.transpile(list.V[1])
-	if err != nil {
-		return "", err
+func (t *Transpiler) transpileMapBinding(m *ex.Map) (js.Expr, *e.Error) {
+	defaults := map[string]js.Expr{}
+	for i := 0; i < len(m.V); i += 2 {
+		if k, ok := m.V[i].(ex.Atom); ok && k.V == "or" {
+			or, ok := m.V[i+1].(*ex.Map)
+			if !ok {
+				return nil, e.FromPosition(m.V[i+1].Pos(),
+					fmt.Sprintf("expected map of defaults: %s", m.V[i+1]))
+			}
+			for j := 0; j < len(or.V); j += 2 {
+				v, err := t.transpileExpression(or.V[j+1])
+				if err != nil {
+					return nil, err
+				}
+				defaults[or.V[j].String()] = v
+			}
+		}
 	}
-	s.WriteString(fmt.Sprintf("%s ? ", cond))
-	then, err := t.transpile(list.V[2])
-	if err != nil {
-		return "", err
+	pattern := &js.Object{}
+	entry := func(key js.Expr, name string) {
+		var value js.Expr = &js.Ident{Name: fixName(name)}
+		if v, ok := defaults[name]; ok {
+			value = &js.Assign{Target: value, Value: v}
+		}
+		pattern.Props = append(pattern.Props, js.Prop{Key: key, Computed: true, Value: value})
 	}
-	s.WriteString(then)
-	s.WriteString(" : ")
-	els, err := t.transpile(list.V[3])
+	for i := 0; i < len(m.V); i += 2 {
+		k, v := m.V[i], m.V[i+1]
+		switch k := k.(type) {
+		case ex.Atom:
+			switch k.V {
+			case "or":
+				continue
+			case "keys":
+				names, ok := v.(*ex.Vec)
+				if !ok {
+					return nil, e.FromPosition(v.Pos(),
+						fmt.Sprintf("expected vector of names: %s", v))
+				}
+				for _, name := range names.V {
+					entry(call("_atom", js.Str(name.String())), name.String())
+				}
+			default:
+				return nil, e.FromPosition(k.Pos(),
+					fmt.Sprintf("unknown map binding option: %s", k))
+			}
+		case ex.Identifier:
+			key, err := t.transpile(v)
+			if err != nil {
+				return nil, err
+			}
+			entry(key, k.V)
+		default:
+			b, err := t.transpileBinding(k)
+			if err != nil {
+				return nil, err
+			}
+			key, err := t.transpile(v)
+			if err != nil {
+				return nil, err
+			}
+			pattern.Props = append(pattern.Props, js.Prop{Key: key, Computed: true, Value: b})
+		}
+	}
+	return pattern, nil
+}
+
+func (t *Transpiler) transpileIf(list *ex.List) (js.Expr, *e.Error) {
+	var els ex.Expr = ex.Nil{P: list.P}
+	if len(list.V) == 4 {
+		els = list.V[3]
+	}
+	return t.transpileTernary(list, list.V[1:3], els)
+}
+
+// Branches that aren't taken are nil.
+//
+//	(cond (< x 0) "negative" (> x 0) "positive")
+//
+//	x < 0 ? "negative" : x > 0 ? "positive" : null
+func (t *Transpiler) transpileCond(list *ex.List) (js.Expr, *e.Error) {
+	var els ex.Expr = ex.Nil{P: list.P}
+	clauses := list.V[1:]
+	for i := 0; i < len(clauses); i += 2 {
+		if isElse(clauses[i]) {
+			els = clauses[i+1]
+			clauses = clauses[:i]
+			break
+		}
+	}
+	return t.transpileTernary(list, clauses, els)
+}
+
+// Chains tests and expressions into ternaries, or
+// into an if statement that assigns a temporary,
+// if a branch or a test after the first needs
+// statements.
+//
+//	(if (< x 0) "negative" "positive") => x < 0 ? "negative" : "positive"
+func (t *Transpiler) transpileTernary(list *ex.List, clauses []ex.Expr, els ex.Expr) (js.Expr, *e.Error) {
+	exprs := append(append([]ex.Expr{}, clauses...), els)
+	codes, ok, err := t.transpileLazy(exprs, func(i int, expr ex.Expr) (js.Expr, *e.Error) {
+		if i%2 == 0 && i < len(clauses) {
+			return t.transpileCondition(expr)
+		}
+		return t.transpile(expr)
+	})
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if !ok {
+		return t.transpileValue(list)
+	}
+	code := codes[len(codes)-1]
+	for i := len(clauses) - 2; i >= 0; i -= 2 {
+		code = &js.Cond{Test: codes[i], Then: codes[i+1], Else: code}
 	}
-	s.WriteString(els)
-	s.WriteString(")()")
-	return s.String(), nil
+	return code, nil
 }
 
-func (t *Transpiler) transpileWhile(list *ex.List) (string, *e.Error) {
-	var s strings.Builder
-	cond, err := t.transpile(list.V[1])
+// Forms that are statements in JS, assigned to a
+// temporary whose value is used.
+//
+//	(f (case x 1 "one" "many")) => switch (x) { case 1: __t1 = "one"; break; ... } f(__t1)
+func (t *Transpiler) transpileValue(expr ex.Expr) (js.Expr, *e.Error) {
+	name := t.temp(nil)
+	if err := t.transpileTo(expr, target{name: name}); err != nil {
+		return nil, err
+	}
+	return name, nil
+}
+
+// While is a statement, and nil as a value. A
+// condition that needs statements is tested
+// inside of the loop.
+//
+//	(while (< i 10) (set i (+ i 1))) => while (i < 10) { i = i + 1; }
+func (t *Transpiler) transpileWhile(list *ex.List) (js.Expr, *e.Error) {
+	t.pushBlock(false)
+	cond, err := t.transpileCondition(list.V[1])
+	pre := t.popBlock()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	s.WriteString(fmt.Sprintf("while (%s) { ", cond))
-	t.setState(state.IN_STATEMENT)
-	defer t.restoreState()
-	body, err := t.transpile(list.V[2])
+	body, err := t.transpileBlock(list.V[2], discard)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if len(pre) > 0 {
+		brk := &js.If{Test: &js.Unary{Op: "!", Arg: cond}, Then: &js.Block{Body: []js.Stmt{&js.Break{}}}}
+		body.Body = append(append(pre, brk), body.Body...)
+		cond = &js.Lit{Raw: "true"}
 	}
-	s.WriteString(body)
-	s.WriteString(" };")
-	return s.String(), nil
+	t.emit(&js.While{Test: cond, Body: body})
+	return t.nilValue(), nil
 }
 
-func (t *Transpiler) transpileDo(list *ex.List) (string, *e.Error) {
-	t.setState(state.NO_SEMICOLON)
-	defer t.restoreState()
-	var s strings.Builder
-	s.WriteString("(() => { ")
+// Every expression but the last is a statement.
+// A do that declares names is a block, like in
+// statement position, and its value a temporary.
+//
+//	(f (do (g) 1))       => g(); f(1)
+//	(f (do (var x 1) x)) => let __t1; { let x = 1; __t1 = x; } f(__t1)
+func (t *Transpiler) transpileDo(list *ex.List) (js.Expr, *e.Error) {
 	rest := list.V[1:]
-	for i, expr := range rest {
-		code, err := t.transpile(expr)
-		if err != nil {
-			return "", err
-		}
-		if i == len(rest)-1 && !t.hasState(state.IN_STATEMENT) {
-			s.WriteString("return ")
+	if len(rest) == 0 {
+		return t.nilValue(), nil
+	}
+	top := t.blocks[len(t.blocks)-1].top
+	temps, labels := t.temps, t.labels
+	t.pushBlock(false)
+	for _, expr := range rest[:len(rest)-1] {
+		if err := t.transpileTo(expr, discard); err != nil {
+			t.dropBlock()
+			return nil, err
 		}
-		s.WriteString(code)
-		s.WriteString("; ")
 	}
-	s.WriteString("})()")
-	if !t.hasState(state.NO_SEMICOLON) {
-		s.WriteByte(';')
+	code, err := t.transpile(rest[len(rest)-1])
+	if err != nil {
+		t.dropBlock()
+		return nil, err
 	}
-	return s.String(), nil
+	if top || !slices.ContainsFunc(t.blocks[len(t.blocks)-1].stmts, declares) {
+		t.emit(t.popBlock()...)
+		return code, nil
+	}
+	t.dropBlock()
+	t.temps, t.labels = temps, labels
+	name := t.temp(nil)
+	return name, t.transpileDoBlock(rest, target{name: name}, false)
 }
 
-func (t *Transpiler) transpileVar(list *ex.List) (string, *e.Error) {
-	name := fixName(list.V[1].String())
-	v, err := t.transpile(list.V[2])
+func (t *Transpiler) transpileThrow(list *ex.List) (js.Expr, *e.Error) {
+	code, err := t.transpile(list.V[1])
 	if err != nil {
-		return "", err
-	}
-	if t.hasState(state.NO_SEMICOLON) {
-		return fmt.Sprintf("let %s = %s", name, v), nil
-	} else {
-		return fmt.Sprintf("let %s = %s;", name, v), nil
+		return nil, err
 	}
+	t.emit(&js.Throw{Arg: code})
+	return t.nilValue(), nil
 }
 
-func (t *Transpiler) transpileSet(list *ex.List) (string, *e.Error) {
-	name, err := t.transpile(list.V[1])
-	if err != nil {
-		return "", err
+func (t *Transpiler) transpileAwait(list *ex.List) (js.Expr, *e.Error) {
+	if t.inSyncFn() {
+		return nil, e.FromPosition(list.Pos(),
+			fmt.Sprintf("await is only allowed in async functions: %s", list))
 	}
-	name = fixName(name)
-	code, err := t.transpile(list.V[2])
+	code, err := t.transpile(list.V[1])
 	if err != nil {
-		return "", err
-	}
-	if t.hasState(state.NO_SEMICOLON) {
-		return fmt.Sprintf("%s = %s", name, code), nil
-	} else {
-		return fmt.Sprintf("%s = %s;", name, code), nil
+		return nil, err
 	}
+	return &js.Await{Arg: code}, nil
 }
 
-func (t *Transpiler) transpileGet(list *ex.List) (string, *e.Error) {
-	ee, err := t.transpile(list.V[1])
+func (t *Transpiler) transpileYield(list *ex.List) (js.Expr, *e.Error) {
+	if t.fnState() != state.IN_GEN_FN {
+		return nil, e.FromPosition(list.Pos(),
+			fmt.Sprintf("yield is only allowed in generator functions: %s", list))
+	}
+	code, err := t.transpile(list.V[1])
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	i, err := t.transpile(list.V[2])
+	return &js.Yield{Arg: code}, nil
+}
+
+// typeof is an operator, so it can't be called.
+//
+//	(typeof x) => typeof x
+func (t *Transpiler) transpileTypeof(list *ex.List) (js.Expr, *e.Error) {
+	if len(list.V) != 2 {
+		return nil, e.FromPosition(list.Pos(),
+			fmt.Sprintf("typeof requires one argument: %s", list))
+	}
+	code, err := t.transpile(list.V[1])
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return fmt.Sprintf("%s[%s]", ee, i), nil
+	return &js.Unary{Op: "typeof", Arg: code}, nil
 }
 
-func (t *Transpiler) transpileMap(e *ex.Map) (string, *e.Error) {
-	var s strings.Builder
-	s.WriteString("({")
-	for i, expr := range e.V {
-		code, err := t.transpile(expr)
+// Rebinds every binding at once and continues.
+//
+//	(recur (+ i 1) acc) => [i, acc] = [i + 1, acc]; continue;
+func (t *Transpiler) transpileRecur(list *ex.List) *e.Error {
+	bindings := t.recur[len(t.recur)-1]
+	args := list.V[1:]
+	variadic := false
+	if len(bindings) > 0 {
+		_, variadic = bindings[len(bindings)-1].(*js.Spread)
+	}
+	if variadic && len(args) < len(bindings)-1 {
+		return e.FromPosition(list.Pos(),
+			fmt.Sprintf("recur expected at least %d arguments, got %d: %s",
+				len(bindings)-1, len(args), list))
+	} else if !variadic && len(args) != len(bindings) {
+		return e.FromPosition(list.Pos(),
+			fmt.Sprintf("recur expected %d arguments, got %d: %s",
+				len(bindings), len(args), list))
+	}
+	if len(bindings) > 0 {
+		codes, err := t.transpileAll(args)
 		if err != nil {
-			return "", err
-		}
-		s.WriteString(code)
-		if i%2 == 0 {
-			s.WriteByte(':')
-		}
-		if i < len(e.V)-1 {
-			s.WriteByte(' ')
+			return err
 		}
+		t.emit(&js.ExprStmt{X: &js.Assign{
+			Target: &js.Array{Elems: bindings},
+			Value:  &js.Array{Elems: codes},
+		}})
+	}
+	t.emit(&js.Continue{})
+	return nil
+}
+
+// Var declares a name in the enclosing block,
+// and is the name as a value. At the top level it
+// is a JS var, so that the REPL can declare it again.
+//
+//	(var x 1) => let x = 1;
+func (t *Transpiler) transpileVar(list *ex.List) (js.Expr, *e.Error) {
+	name := &js.Ident{Name: fixName(list.V[1].String())}
+	v, err := t.transpile(list.V[2])
+	if err != nil {
+		return nil, err
 	}
-	s.WriteString("})")
-	return s.String(), nil
+	kind := "let"
+	if t.blocks[len(t.blocks)-1].top {
+		kind = "var"
+	}
+	t.emit(&js.VarDecl{Kind: kind, Decls: []js.Declarator{{Target: name, Init: v}}})
+	return name, nil
 }
 
-func (t *Transpiler) transpileVec(e *ex.Vec) (string, *e.Error) {
-	var s strings.Builder
-	s.WriteByte('[')
-	for i, expr := range e.V {
-		code, err := t.transpile(expr)
+func (t *Transpiler) transpileSet(list *ex.List) (js.Expr, *e.Error) {
+	if l, ok := list.V[1].(*ex.List); ok && l.IsHead(ex.Identifier{V: "get"}) {
+		codes, err := t.transpileAll([]ex.Expr{l.V[1], l.V[2], list.V[2]})
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		s.WriteString(code)
-		if i < len(e.V)-1 {
-			s.WriteString(", ")
+		return &js.Assign{Target: &js.Index{Obj: codes[0], Index: codes[1]}, Value: codes[2]}, nil
+	} else if l, ok := list.V[1].(*ex.List); ok && len(l.V) == 2 && strings.HasPrefix(l.V[0].String(), ".-") {
+		codes, err := t.transpileAll([]ex.Expr{l.V[1], list.V[2]})
+		if err != nil {
+			return nil, err
 		}
+		return &js.Assign{Target: property(codes[0], l.V[0].String()[2:]), Value: codes[1]}, nil
+	}
+	target, err := t.transpile(list.V[1])
+	if err != nil {
+		return nil, err
+	}
+	codes, err := t.transpileAll(list.V[2:3])
+	if err != nil {
+		return nil, err
 	}
-	s.WriteByte(']')
-	return s.String(), nil
+	return &js.Assign{Target: target, Value: codes[0]}, nil
 }
 
-func (t *Transpiler) transpileVariableArg(e *ex.VariableArg) (string, *e.Error) {
-	arg, err := t.transpile(e.V)
+func (t *Transpiler) transpileGet(list *ex.List) (js.Expr, *e.Error) {
+	codes, err := t.transpileAll(list.V[1:3])
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if t.persistent() {
+		return call("_get", codes...), nil
 	}
-	return fmt.Sprintf("...%s", arg), nil
+	return &js.Index{Obj: codes[0], Index: codes[1]}, nil
 }
 
-func (t *Transpiler) transpileMacro(m *ex.Macro) (string, *e.Error) {
-	lines := strings.Split(m.String(), "\n")
-	return fmt.Sprintf("// %s\n\n", strings.Join(lines, "\n// ")), nil
+func (t *Transpiler) transpileMap(m *ex.Map) (js.Expr, *e.Error) {
+	codes, err := t.transpileAll(m.V)
+	if err != nil {
+		return nil, err
+	}
+	if t.persistent() {
+		return call("_hashMap", codes...), nil
+	}
+	obj := &js.Object{}
+	for i := 0; i+1 < len(codes); i += 2 {
+		// Atoms turn into ":name" as keys.
+		_, atom := m.V[i].(ex.Atom)
+		obj.Props = append(obj.Props, js.Prop{Key: codes[i], Computed: atom, Value: codes[i+1]})
+	}
+	return obj, nil
 }
 
-func (t *Transpiler) transpileQuote(expr *ex.Quote) (string, *e.Error) {
-	e, err := t.transpile(expr.E)
+func (t *Transpiler) transpileVec(v *ex.Vec) (js.Expr, *e.Error) {
+	codes, err := t.transpileAll(v.V)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if t.persistent() {
+		return call("_vector", codes...), nil
 	}
-	return fmt.Sprintf("eval(%q)", e), nil
+	return &js.Array{Elems: codes}, nil
 }
 
-func (t *Transpiler) transpileQuasiquote(expr *ex.Quasiquote) (string, *e.Error) {
-	t.setState(state.IN_QUASI)
-	defer t.restoreState()
-	e, err := t.transpile(expr.E)
+func (t *Transpiler) transpileVariableArg(v *ex.VariableArg) (js.Expr, *e.Error) {
+	arg, err := t.transpile(v.V)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return e, nil
+	return &js.Spread{Arg: arg}, nil
 }
 
-func (t *Transpiler) transpileUnquote(expr *ex.Unquote) (string, *e.Error) {
-	if t.hasState(state.IN_QUASI) {
-		e, err := t.transpile(expr.E)
-		if err != nil {
-			return "", err
+// Macros are expanded away, and are
+// left as comments.
+func (t *Transpiler) transpileMacro(m *ex.Macro) (js.Expr, *e.Error) {
+	t.emit(&js.Comment{Text: m.String()})
+	return t.nilValue(), nil
+}
+
+// Quoted expressions become data.
+//
+//	'(a :b [1 "c"]) => [_symbol("a"), _atom("b"), [1, "c"]]
+func (t *Transpiler) transpileQuote(expr *ex.Quote) (js.Expr, *e.Error) {
+	return t.transpileQuoted(expr.E)
+}
+
+func (t *Transpiler) transpileQuoted(expr ex.Expr) (js.Expr, *e.Error) {
+	switch expr := expr.(type) {
+	case ex.Nil:
+		return t.nilValue(), nil
+	case ex.Identifier:
+		return call("_symbol", js.Str(expr.V)), nil
+	case ex.Op:
+		return call("_symbol", js.Str(expr.String())), nil
+	case *ex.List:
+		return t.transpileQuotedSeq(expr.V)
+	case *ex.Vec:
+		return t.transpileQuotedSeq(expr.V)
+	case *ex.Map:
+		obj := &js.Object{}
+		for i := 0; i < len(expr.V); i += 2 {
+			k, err := t.transpileQuoted(expr.V[i])
+			if err != nil {
+				return nil, err
+			}
+			v, err := t.transpileQuoted(expr.V[i+1])
+			if err != nil {
+				return nil, err
+			}
+			obj.Props = append(obj.Props, js.Prop{Key: k, Computed: true, Value: v})
 		}
-		return fmt.Sprintf("eval(%q)", e), nil
-	} else {
-		return "", e.FromPosition(expr.Pos(), "misplaced unquote")
+		return obj, nil
+	case *ex.Fn:
+		return t.transpileQuoted(expr.ToList())
+	case *ex.AnonymousFn:
+		return t.transpileQuoted(expr.ToList())
+	case *ex.Macro:
+		return t.transpileQuoted(expr.ToList())
+	case *ex.Quote:
+		return t.transpileQuotedSeq([]ex.Expr{ex.Identifier{V: "quote"}, expr.E})
+	case *ex.Quasiquote:
+		return t.transpileQuotedSeq([]ex.Expr{ex.Identifier{V: "quasiquote"}, expr.E})
+	case *ex.Unquote:
+		return t.transpileQuotedSeq([]ex.Expr{ex.Identifier{V: "unquote"}, expr.E})
+	case *ex.UnquoteSplicing:
+		return t.transpileQuotedSeq([]ex.Expr{ex.Identifier{V: "unquote-splicing"}, expr.E})
+	default:
+		return t.transpile(expr)
 	}
 }
 
-func (t *Transpiler) transpileUnquoteSplicing(expr *ex.UnquoteSplicing) (string, *e.Error) {
-	if t.hasState(state.IN_QUASI) {
-		e, err := t.transpile(expr.E)
+func (t *Transpiler) transpileQuotedSeq(exprs []ex.Expr) (js.Expr, *e.Error) {
+	arr := &js.Array{}
+	for _, expr := range exprs {
+		if v, ok := expr.(*ex.VariableArg); ok {
+			code, err := t.transpileQuoted(v.V)
+			if err != nil {
+				return nil, err
+			}
+			arr.Elems = append(arr.Elems, call("_symbol", js.Str("&")), code)
+			continue
+		}
+		code, err := t.transpileQuoted(expr)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
-		return fmt.Sprintf("...eval(%q)", e), nil
-	} else {
-		return "", e.FromPosition(expr.Pos(), "misplaced unquote splicing")
+		arr.Elems = append(arr.Elems, code)
+	}
+	return arr, nil
+}
+
+func (t *Transpiler) transpileQuasiquote(expr *ex.Quasiquote) (js.Expr, *e.Error) {
+	t.setState(state.IN_QUASI)
+	defer t.restoreState()
+	return t.transpile(expr.E)
+}
+
+// Unquoted code is evaluated where the
+// template is.
+//
+//	`(a ~b) => [a, eval("b")]
+func (t *Transpiler) transpileUnquote(expr *ex.Unquote) (js.Expr, *e.Error) {
+	if !t.hasState(state.IN_QUASI) {
+		return nil, e.FromPosition(expr.Pos(), "misplaced unquote")
+	}
+	code, err := t.transpileExpression(expr.E)
+	if err != nil {
+		return nil, err
+	}
+	return call("eval", js.Str(js.PrintExpr(code))), nil
+}
+
+func (t *Transpiler) transpileUnquoteSplicing(expr *ex.UnquoteSplicing) (js.Expr, *e.Error) {
+	if !t.hasState(state.IN_QUASI) {
+		return nil, e.FromPosition(expr.Pos(), "misplaced unquote splicing")
+	}
+	code, err := t.transpileExpression(expr.E)
+	if err != nil {
+		return nil, err
 	}
+	return &js.Spread{Arg: call("eval", js.Str(js.PrintExpr(code)))}, nil
 }
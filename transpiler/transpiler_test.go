@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	e "github.com/fholmqvist/remlisp/err"
 	h "github.com/fholmqvist/remlisp/highlight"
 	"github.com/fholmqvist/remlisp/lexer"
 	"github.com/fholmqvist/remlisp/parser"
@@ -16,67 +17,75 @@ func TestTranspiler(t *testing.T) {
 	}{
 		{
 			input:  "nil",
-			output: "nil",
+			output: "null;",
 		},
 		{
 			input:  "0",
-			output: "0",
+			output: "0;",
 		},
 		{
 			input:  "1234",
-			output: "1234",
+			output: "1234;",
 		},
 		{
 			input:  "-1234",
-			output: "-1234",
+			output: "-1234;",
 		},
 		{
 			input:  "0.0",
-			output: "0.000000",
+			output: "0.000000;",
 		},
 		{
 			input:  "1234.0",
-			output: "1234.000000",
+			output: "1234.000000;",
 		},
 		{
 			input:  "-1234.0",
-			output: "-1234.000000",
+			output: "-1234.000000;",
 		},
 		{
 			input:  "true",
-			output: "true",
+			output: "true;",
 		},
 		{
 			input:  "false",
-			output: "false",
+			output: "false;",
 		},
 		{
 			input:  "example_identifier",
-			output: "example_identifier",
+			output: "example_identifier;",
 		},
 		{
 			input:  "\"example_string\"",
-			output: "\"example_string\"",
+			output: "\"example_string\";",
 		},
 		{
 			input:  ":a",
-			output: "\":a\"",
+			output: "_atom(\"a\");",
+		},
+		{
+			input:  "(:a {:a 1})",
+			output: "_atom(\"a\")({ [_atom(\"a\")]: 1 });",
+		},
+		{
+			input:  "(:a {} 2)",
+			output: "_atom(\"a\")({}, 2);",
 		},
 		{
 			input:  "(+ 1 1 1)",
-			output: "(1 + 1 + 1)",
+			output: "1 + 1 + 1;",
 		},
 		{
 			input:  "(- 1 1 1)",
-			output: "(1 - 1 - 1)",
+			output: "1 - 1 - 1;",
 		},
 		{
 			input:  "(* 1 1 1)",
-			output: "(1 * 1 * 1)",
+			output: "1 * 1 * 1;",
 		},
 		{
 			input:  "(/ 1 1 1)",
-			output: "(1 / 1 / 1)",
+			output: "1 / 1 / 1;",
 		},
 		{
 			input:  "(add 1 1)",
@@ -84,39 +93,39 @@ func TestTranspiler(t *testing.T) {
 		},
 		{
 			input:  "(1 2 3 4)",
-			output: "[1, 2, 3, 4]",
+			output: "[1, 2, 3, 4];",
 		},
 		{
 			input:  "[1 2 3 4]",
-			output: "[1, 2, 3, 4]",
+			output: "[1, 2, 3, 4];",
 		},
 		{
 			input:  "(fn add [x y] (+ x y))",
-			output: "function add(x, y) { return (x + y) }",
+			output: "function add(x, y) { return x + y; }",
 		},
 		{
 			input:  "(fn id-array [& x] x)",
-			output: "function id_array(...x) { return x }",
+			output: "function id$_array(...x) { return x; }",
 		},
 		{
 			input:  "(fn pair->sum [[x y]] (+ x y))",
-			output: "function pair_arrow_sum([x, y]) { return (x + y) }",
+			output: "function pair$_$GT$sum([x, y]) { return x + y; }",
 		},
 		{
 			input:  "(. (Array 10) (fill 1) (map (fn [_ i] i)))",
-			output: "Array(10).fill(1).map((_, i) => i)",
+			output: "Array(10).fill(1).map((_, i) => i);",
 		},
 		{
 			input:  "(if (< 1 2) 1 2)",
-			output: "(() => (1 < 2) ? 1 : 2)()",
+			output: "1 < 2 ? 1 : 2;",
 		},
 		{
 			input:  "(do 1 2 3)",
-			output: "(() => { 1; 2; return 3; })()",
+			output: "3;",
 		},
 		{
 			input:  "(var x 1)",
-			output: "let x = 1;",
+			output: "var x = 1;",
 		},
 		{
 			input:  "(set x 2)",
@@ -124,15 +133,451 @@ func TestTranspiler(t *testing.T) {
 		},
 		{
 			input:  "(get [1 2] 0)",
-			output: "[1, 2][0]",
+			output: "[1, 2][0];",
 		},
 		{
 			input:  "{:a 1}",
-			output: "({\":a\": 1})",
+			output: "({ [_atom(\"a\")]: 1 });",
+		},
+		{
+			input:  "{:a 1 \"b\" 2}",
+			output: "({ [_atom(\"a\")]: 1, \"b\": 2 });",
+		},
+		{
+			input:  "(= 1 1)",
+			output: "1 === 1;",
+		},
+		{
+			input:  "(!= 1 2)",
+			output: "1 !== 2;",
 		},
 		{
 			input:  "(while (< 1 2) (println \"infinite loop!\"))",
-			output: "while ((1 < 2)) { println(\"infinite loop!\"); };",
+			output: "while (1 < 2) { println(\"infinite loop!\"); }",
+		},
+		{
+			input:  "'(a + :b [1 \"c\" nil])",
+			output: "[_symbol(\"a\"), _symbol(\"+\"), _atom(\"b\"), [1, \"c\", null]];",
+		},
+		{
+			input:  "'{:a b}",
+			output: "({ [_atom(\"a\")]: _symbol(\"b\") });",
+		},
+		{
+			input:  "'(fn [x] 'x)",
+			output: "[_symbol(\"fn\"), [_symbol(\"x\")], [_symbol(\"quote\"), _symbol(\"x\")]];",
+		},
+		{
+			input:  "(try (risky) (catch e (println e)) (finally (cleanup)))",
+			output: "var __t1; try { __t1 = risky(); } catch (e) { __t1 = println(e); } finally { cleanup(); } __t1;",
+		},
+		{
+			input:  "(throw (Error \"oops\"))",
+			output: "throw Error(\"oops\");",
+		},
+		{
+			input:  "(async-fn fetch-json [url] (await (. (await (fetch url)) (json))))",
+			output: "async function fetch$_json(url) { return await (await fetch(url)).json(); }",
+		},
+		{
+			input:  "(async (fn [x] (await x)))",
+			output: "async (x) => await x;",
+		},
+		{
+			input:  "(async-fn f [x] (if x (await x) (do (await x) 1)))",
+			output: "async function f(x) { if (_truthy(x)) { return await x; } else { await x; return 1; } }",
+		},
+		{
+			input:  "(async-fn f [] (map (fn [x] (if x 1 2)) xs))",
+			output: "async function f() { return map((x) => { if (_truthy(x)) { return 1; } else { return 2; } }, xs); }",
+		},
+		{
+			input:  "(await (fetch url))",
+			output: "(async () => { return await fetch(url); })();",
+		},
+		{
+			input:  "(var x (await (f))) (g x)",
+			output: "var x; (async () => { x = await f(); return g(x); })();",
+		},
+		{
+			input:  "(= (typeof x) \"string\")",
+			output: "typeof x === \"string\";",
+		},
+		{
+			input:  "(gen-fn naturals [] (do (var n 0) (while true (do (yield n) (set n (+ n 1))))))",
+			output: "function* naturals() { let n = 0; while (true) { yield n; n = n + 1; } return null; }",
+		},
+		{
+			input:  "(gen-fn [x] (if x (yield 1) 2))",
+			output: "(function* (x) { if (_truthy(x)) { return yield 1; } else { return 2; } });",
+		},
+		{
+			input:  "(do (var i 0) (while (< i 3) (set i (+ i 1))))",
+			output: "var i = 0; while (i < 3) { i = i + 1; }",
+		},
+		{
+			input:  "(loop [i 0 acc []] (if (< i 3) (recur (+ i 1) (acc.concat [i])) acc))",
+			output: "var __t1; { let __t2 = 0, i = __t2, __t3 = []; __loop1: while (true) { let i = __t2, acc = __t3; if (i < 3) { [__t2, __t3] = [i + 1, acc.concat([i])]; continue; } else { __t1 = acc; break __loop1; } } } __t1;",
+		},
+		{
+			input:  "(fn count [n acc] (if (= n 0) acc (do (println n) (recur (- n 1) (+ acc 1)))))",
+			output: "function count(n, acc) { let __t1 = n, __t2 = acc; while (true) { let n = __t1, acc = __t2; if (n === 0) { return acc; } else { println(n); [__t1, __t2] = [n - 1, acc + 1]; continue; } } }",
+		},
+		{
+			input:  "(fn [[a b] n] (if n (recur [b a] nil) a))",
+			output: "(__t1, n) => { let __t2 = n; while (true) { let [a, b] = __t1, n = __t2; if (_truthy(n)) { [__t1, __t2] = [[b, a], null]; continue; } else { return a; } } };",
+		},
+		{
+			input:  "(fn [x & xs] (if x (recur xs) x))",
+			output: "(x, ...xs) => { let __t1 = x, __t2 = xs; while (true) { let x = __t1, xs = __t2; if (_truthy(x)) { [__t1, ...__t2] = [xs]; continue; } else { return x; } } };",
+		},
+		{
+			input:  "(fn f [n] (loop [i n] (if i (recur (- i 1)) (f 1))))",
+			output: "function f(n) { { let __t1 = n; while (true) { let i = __t1; if (_truthy(i)) { [__t1] = [i - 1]; continue; } else { return f(1); } } } }",
+		},
+		{
+			input:  "(fn area ([r] (* r r)) ([w h] (* w h)))",
+			output: "function area(...__args) { if (__args.length === 1) { let [r] = __args; return r * r; } if (__args.length === 2) { let [w, h] = __args; return w * h; } throw _arityError(\"area\", __args.length, \"1 or 2\"); }",
+		},
+		{
+			input:  "(fn ([] 0) ([x (y 1)] y) ([x y z & more] more))",
+			output: "(...__args) => { if (__args.length === 0) { return 0; } if (__args.length >= 1 && __args.length <= 2) { let [x, y = 1] = __args; return y; } if (__args.length >= 3) { let [x, y, z, ...more] = __args; return more; } throw _arityError(\"fn\", __args.length, \"0, 1 to 2 or 3 or more\"); };",
+		},
+		{
+			input:  "(fn greet [name (greeting \"hi\")] greeting)",
+			output: "function greet(name, greeting = \"hi\") { return greeting; }",
+		},
+		{
+			input:  "(fn run [cmd & {:keys [verbose retries] :or {retries 3}}] verbose)",
+			output: "function run(cmd, ...__opts) { let { [_atom(\"verbose\")]: verbose, [_atom(\"retries\")]: retries = 3 } = _kwargs(__opts); return verbose; }",
+		},
+		{
+			input:  "(fn [{:keys [a] b :b} [c & cs]] a)",
+			output: "({ [_atom(\"a\")]: a, [_atom(\"b\")]: b }, [c, ...cs]) => a;",
+		},
+		{
+			input:  "(if x 1)",
+			output: "_truthy(x) ? 1 : null;",
+		},
+		{
+			input:  "(when x (println x) x)",
+			output: "var __t1; if (_truthy(x)) { println(x); __t1 = x; } else { __t1 = null; } __t1;",
+		},
+		{
+			input:  "(unless x 1)",
+			output: "_truthy(x) ? null : 1;",
+		},
+		{
+			input:  "(cond (< x 0) \"negative\" (> x 0) \"positive\" :else \"zero\")",
+			output: "x < 0 ? \"negative\" : x > 0 ? \"positive\" : \"zero\";",
+		},
+		{
+			input:  "(cond a 1)",
+			output: "_truthy(a) ? 1 : null;",
+		},
+		{
+			input:  "(case x 1 \"one\" (:a :b) \"atom\" \"other\")",
+			output: "var __t1; switch (x) { case 1: __t1 = \"one\"; break; case _atom(\"a\"): case _atom(\"b\"): __t1 = \"atom\"; break; default: __t1 = \"other\"; break; } __t1;",
+		},
+		{
+			input:  "(case x \"a\" 1)",
+			output: "var __t1; switch (x) { case \"a\": __t1 = 1; break; default: throw new Error(\"no matching case: \" + x); } __t1;",
+		},
+		{
+			input:  "(fn f [n] (cond (= n 0) :done :else (recur (- n 1))))",
+			output: "function f(n) { let __t1 = n; while (true) { let n = __t1; if (n === 0) { return _atom(\"done\"); } else { [__t1] = [n - 1]; continue; } } }",
+		},
+		{
+			input:  "(fn f [] (do (do (var x 1) (g x)) (do (var x 2) (g x))))",
+			output: "function f() { { let x = 1; g(x); } { let x = 2; return g(x); } }",
+		},
+		{
+			input:  "(fn f [] (g (do (var x 1) x)))",
+			output: "function f() { let __t1; { let x = 1; __t1 = x; } return g(__t1); }",
+		},
+		{
+			input:  "(fn f [n] (case n 0 :done (recur (- n 1))))",
+			output: "function f(n) { let __t1 = n; while (true) { let n = __t1; switch (n) { case 0: return _atom(\"done\"); default: [__t1] = [n - 1]; continue; } } }",
+		},
+		{
+			input:  "(fn f [n] (when (> n 0) (recur (- n 1))))",
+			output: "function f(n) { let __t1 = n; while (true) { let n = __t1; if (n > 0) { [__t1] = [n - 1]; continue; } else { return null; } } }",
+		},
+		{
+			input:  "(if (and a (< b 1)) 1 2)",
+			output: "_truthy(a) && b < 1 ? 1 : 2;",
+		},
+		{
+			input:  "(if (>= x 1) 1 2)",
+			output: "x >= 1 ? 1 : 2;",
+		},
+		{
+			input:  "(and (< a 1) (> b 2))",
+			output: "a < 1 && b > 2;",
+		},
+		{
+			input:  "(or x 1)",
+			output: "_truthy(x) ? x : 1;",
+		},
+		{
+			input:  "(and a b c)",
+			output: "!_truthy(a) ? a : !_truthy(b) ? b : c;",
+		},
+		{
+			input:  "(while x (f))",
+			output: "while (_truthy(x)) { f(); }",
+		},
+		{
+			input:  "(= x nil)",
+			output: "x == null;",
+		},
+		{
+			input:  "(!= nil (get xs 0))",
+			output: "xs[0] != null;",
+		},
+		{
+			input:  "(case x nil 0 1)",
+			output: "var __t1; switch (x) { case null: case undefined: __t1 = 0; break; default: __t1 = 1; break; } __t1;",
+		},
+		{
+			input:  "(< a b c)",
+			output: "a < b && b < c;",
+		},
+		{
+			input:  "(< a (f) c)",
+			output: "var __t1, __t2, __t3; __t1 = a, __t2 = f(), __t3 = c, __t1 < __t2 && __t2 < __t3;",
+		},
+		{
+			input:  "(!= a b c)",
+			output: "!(_equals(a, b) && _equals(b, c));",
+		},
+		{
+			input:  "(= a b)",
+			output: "_equals(a, b);",
+		},
+		{
+			input:  "(!= a b)",
+			output: "!_equals(a, b);",
+		},
+		{
+			input:  "(= [1 2] xs)",
+			output: "_equals([1, 2], xs);",
+		},
+		{
+			input:  "(= x :a)",
+			output: "x === _atom(\"a\");",
+		},
+		{
+			input:  "(= (+ a 1) b)",
+			output: "a + 1 === b;",
+		},
+		{
+			input:  "(- x)",
+			output: "-x;",
+		},
+		{
+			input:  "(- -1)",
+			output: "- -1;",
+		},
+		{
+			input:  "(/ x)",
+			output: "1 / x;",
+		},
+		{
+			input:  "(+)",
+			output: "0;",
+		},
+		{
+			input:  "(*)",
+			output: "1;",
+		},
+		{
+			input:  "(quot 7 2)",
+			output: "Math.trunc(7 / 2);",
+		},
+		{
+			input:  "(rem -7 2)",
+			output: "-7 % 2;",
+		},
+		{
+			input:  "(** -2 2)",
+			output: "(-2) ** 2;",
+		},
+		{
+			input:  "(bit-and a b c)",
+			output: "a & b & c;",
+		},
+		{
+			input:  "(bit-not x)",
+			output: "~x;",
+		},
+		{
+			input:  "(unsigned-bit-shift-right x 1)",
+			output: "x >>> 1;",
+		},
+		{
+			input:  "(reduce + 0 xs)",
+			output: "reduce(_add, 0, xs);",
+		},
+		{
+			input:  "(map - xs)",
+			output: "map(_sub, xs);",
+		},
+		{
+			input:  "(var lt <)",
+			output: "var lt = _lt;",
+		},
+		{
+			input:  "(filter (partial bit-and 1) xs)",
+			output: "filter(partial(_bitAnd, 1), xs);",
+		},
+		{
+			input:  "(get (f x) 1)",
+			output: "f(x)[1];",
+		},
+		{
+			input:  "[(f 1) (g 2)]",
+			output: "[f(1), g(2)];",
+		},
+		{
+			input:  "{:a (f 1)}",
+			output: "({ [_atom(\"a\")]: f(1) });",
+		},
+		{
+			input:  "(new Map [[1 2]])",
+			output: "new Map([[1, 2]]);",
+		},
+		{
+			input:  "(Map. xs)",
+			output: "new Map(xs);",
+		},
+		{
+			input:  "(new (f) 1)",
+			output: "new (f())(1);",
+		},
+		{
+			input:  "(.push xs 1 2)",
+			output: "xs.push(1, 2);",
+		},
+		{
+			input:  "(.trim (f x))",
+			output: "f(x).trim();",
+		},
+		{
+			input:  "(.-length xs)",
+			output: "xs.length;",
+		},
+		{
+			input:  "(.-length (.-data x))",
+			output: "x.data.length;",
+		},
+		{
+			input:  "(.-my-prop x)",
+			output: "x.my$_prop;",
+		},
+		{
+			input:  "(set! (.-a o) 1)",
+			output: "o.a = 1;",
+		},
+		{
+			input:  "(set (.-a (f)) 1)",
+			output: "f().a = 1;",
+		},
+		{
+			input:  "(js/console.log js/globalThis)",
+			output: "console.log(globalThis);",
+		},
+		{
+			input:  "(empty? (into! a_b a-b))",
+			output: "empty$QMARK$(into$BANG$(a_b, a$_b));",
+		},
+		{
+			input:  "(fn default [class] class.name)",
+			output: "function default$(class$) { return class$.name; }",
+		},
+		{
+			input:  "(.-default m)",
+			output: "m.default;",
+		},
+		{
+			input:  "(fn [] this.name)",
+			output: "(function () { return this.name; });",
+		},
+		{
+			input:  "(fn [x] (map (fn [y] (+ y this.n)) x))",
+			output: "(x) => map(function (y) { return y + this.n; }, x);",
+		},
+		{
+			input:  "(js* \"~{} instanceof ~{}\" x Map)",
+			output: "x instanceof Map;",
+		},
+		{
+			input:  "(js* \"typeof ~{}\" (f x))",
+			output: "typeof f(x);",
+		},
+		{
+			input:  "(js* \"debugger\")",
+			output: "debugger;",
+		},
+		{
+			input:  "(f (js* \"~{} ?? 0\" x))",
+			output: "f((x ?? 0));",
+		},
+		{
+			input:  "(f (js* \"throw ~{}\" x))",
+			output: "throw x; f(null);",
+		},
+		{
+			input:  "(fn [n] (do (js* \"if (~{}) { return 1 }\" n) 2))",
+			output: "(n) => { if (n) { return 1 } return 2; };",
+		},
+		{
+			input:  "(while true (do (set x (do 1 2)) (js* \"break\")))",
+			output: "while (true) { x = 2; break; }",
+		},
+		{
+			input:  "(f (g) (do (h) 1))",
+			output: "var __t1; __t1 = g(); h(); f(__t1, 1);",
+		},
+		{
+			input:  "(do (var x 1) (f x))",
+			output: "var x = 1; f(x);",
+		},
+		{
+			input:  "(f (if x (do (g) 1) 2))",
+			output: "var __t1; if (_truthy(x)) { g(); __t1 = 1; } else { __t1 = 2; } f(__t1);",
+		},
+		{
+			input:  "(fn [x] (f (loop [i x] (if i (recur (- i 1)) 0))))",
+			output: "(x) => { let __t1; { let __t2 = x; __loop1: while (true) { let i = __t2; if (_truthy(i)) { [__t2] = [i - 1]; continue; } else { __t1 = 0; break __loop1; } } } return f(__t1); };",
+		},
+		{
+			input:  "(or x (do (f) 1))",
+			output: "var __t1; __t1 = x; if (!_truthy(__t1)) { f(); __t1 = 1; } __t1;",
+		},
+		{
+			input:  "(while (do (f) x) (g))",
+			output: "while (true) { f(); if (!_truthy(x)) { break; } g(); }",
+		},
+		{
+			input:  "(fn [x] (if x (throw (Error. \"no\")) 1))",
+			output: "(x) => { if (_truthy(x)) { throw new Error(\"no\"); } else { return 1; } };",
+		},
+		{
+			input:  "x (y)",
+			output: "x;\ny();",
+		},
+		{
+			input:  "((f 1) 2)",
+			output: "f(1)(2);",
+		},
+		{
+			input:  "((fn [x] x) 1)",
+			output: "((x) => x)(1);",
+		},
+		{
+			input:  "(#(+ % 1) 1)",
+			output: "((__p1) => __p1 + 1)(1);",
 		},
 		{
 			input:  "(macro inc [n] (+ n 1))",
@@ -150,7 +595,197 @@ func TestTranspiler(t *testing.T) {
 	}
 }
 
+func TestTranspilerJSTruthiness(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "(if x 1 2)",
+			output: "x ? 1 : 2;",
+		},
+		{
+			input:  "(or x 1)",
+			output: "x || 1;",
+		},
+		{
+			input:  "(while x (f))",
+			output: "while (x) { f(); }",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			code := getCodeWithOptions(t, tt.input, Options{JSTruthiness: true})
+			if code != tt.output {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n",
+					h.Code(tt.output), h.Code(code))
+			}
+		})
+	}
+}
+
+func TestTranspilerNilUndefined(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "nil",
+			output: "undefined;",
+		},
+		{
+			input:  "'(a nil)",
+			output: "[_symbol(\"a\"), undefined];",
+		},
+		{
+			input:  "(= x nil)",
+			output: "x == null;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			code := getCodeWithOptions(t, tt.input, Options{NilUndefined: true})
+			if code != tt.output {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n",
+					h.Code(tt.output), h.Code(code))
+			}
+		})
+	}
+}
+
+func TestTranspilerPersistent(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "[1 [2]]",
+			output: "_vector(1, _vector(2));",
+		},
+		{
+			input:  "{:a 1}",
+			output: "_hashMap(_atom(\"a\"), 1);",
+		},
+		{
+			input:  "(get xs 0)",
+			output: "_get(xs, 0);",
+		},
+		{
+			input:  "(set (get xs 0) 1)",
+			output: "xs[0] = 1;",
+		},
+		{
+			input:  "(fn f [[a b]] [b a])",
+			output: "function f([a, b]) { return _vector(b, a); }",
+		},
+		{
+			input:  "'[1 2]",
+			output: "[1, 2];",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			code := getCodeWithOptions(t, tt.input, Options{Persistent: true})
+			if code != tt.output {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n",
+					h.Code(tt.output), h.Code(code))
+			}
+		})
+	}
+}
+
+func TestTranspilerError(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{
+			input:  "(fn f [x] (await x))",
+			output: "await is only allowed in async functions",
+		},
+		{
+			input:  "(.-length xs ys)",
+			output: "property access requires one object",
+		},
+		{
+			input:  "(.push)",
+			output: "method call requires an object",
+		},
+		{
+			input:  "(js* \"~{} + ~{}\" 1)",
+			output: "js* expected 2 arguments, got 1",
+		},
+		{
+			input:  "(gen-fn f [xs] (map (fn [x] (yield x)) xs))",
+			output: "yield is only allowed in generator functions",
+		},
+		{
+			input:  "(fn f [n] (+ 1 (recur n)))",
+			output: "recur must be in tail position",
+		},
+		{
+			input:  "(loop [i 0] (recur))",
+			output: "recur expected 1 arguments, got 0",
+		},
+		{
+			input:  "(fn f [x & xs] (recur))",
+			output: "recur expected at least 1 arguments, got 0",
+		},
+		{
+			input:  "(recur 1)",
+			output: "recur must be in tail position",
+		},
+		{
+			input:  "(fn f [(x 1 2)] x)",
+			output: "expected binding and default value",
+		},
+		{
+			input:  "(fn f [{:all [x]}] x)",
+			output: "unknown map binding option",
+		},
+		{
+			input:  "(yield 1)",
+			output: "yield is only allowed in generator functions",
+		},
+		{
+			input:  "(typeof x y)",
+			output: "typeof requires one argument",
+		},
+		{
+			input:  "(quot 1 2 3)",
+			output: "quot requires two arguments",
+		},
+		{
+			input:  "(<)",
+			output: "< requires at least one argument",
+		},
+		{
+			input:  "(-)",
+			output: "- requires at least one argument",
+		},
+		{
+			input:  "(async-fn f [xs] (map (fn [x] (await x)) xs))",
+			output: "await is only allowed in async functions",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := getResult(t, tt.input)
+			if err == nil {
+				t.Fatal(h.Bold(h.Red("\n\nexpected error, got nil\n")))
+			}
+			if !strings.Contains(err.Msg, tt.output) {
+				t.Fatalf("\n\nexpected\n\n%s\n\ngot\n\n%s\n\n", tt.output, err.Msg)
+			}
+		})
+	}
+}
+
 func getCode(t *testing.T, input string) string {
+	return getCodeWithOptions(t, input, Options{})
+}
+
+func getCodeWithOptions(t *testing.T, input string, opts Options) string {
 	bb := []byte(input)
 	lexer := lexer.New()
 	tokens, erre := lexer.Lex(bb)
@@ -162,10 +797,25 @@ func getCode(t *testing.T, input string) string {
 	if erre != nil {
 		t.Fatalf("\n\n%s:\n\n%v\n\n", h.Bold("error"), erre.String(bb))
 	}
-	trn := New()
+	trn := NewWithOptions(opts)
 	code, err := trn.Transpile(exprs)
 	if err != nil {
 		t.Fatal(err)
 	}
 	return strings.TrimSpace(code)
 }
+
+func getResult(t *testing.T, input string) (string, *e.Error) {
+	bb := []byte(input)
+	lexer := lexer.New()
+	tokens, erre := lexer.Lex(bb)
+	if erre != nil {
+		t.Fatalf("\n\n%s:\n\n%v\n\n", h.Bold("error"), erre.String(bb))
+	}
+	parser := parser.New(lexer)
+	exprs, erre := parser.Parse(tokens)
+	if erre != nil {
+		t.Fatalf("\n\n%s:\n\n%v\n\n", h.Bold("error"), erre.String(bb))
+	}
+	return New().Transpile(exprs)
+}